@@ -0,0 +1,55 @@
+package auth
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIKeyHeader carries the api key of a request
+const APIKeyHeader = "X-API-Key"
+
+type apiKey struct {
+	key       []byte
+	principal *Principal
+}
+
+// APIKeyAuthenticator checks the X-API-Key header against the known keys
+type APIKeyAuthenticator struct {
+	keys []*apiKey
+}
+
+// NewAPIKeyAuthenticator parses entries formatted as "name:key:org1,org2",
+// "*" as org allows all orgs
+func NewAPIKeyAuthenticator(entries []string) (*APIKeyAuthenticator, error) {
+	a := &APIKeyAuthenticator{}
+	for _, entry := range entries {
+		fields := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(fields) != 3 || fields[0] == "" || fields[1] == "" || fields[2] == "" {
+			return nil, fmt.Errorf("bad api key entry %q, should be name:key:orgs", entry)
+		}
+		a.keys = append(a.keys, &apiKey{
+			key: []byte(fields[1]),
+			principal: &Principal{
+				Name: fields[0],
+				Orgs: strings.Split(fields[2], ","),
+			},
+		})
+	}
+	return a, nil
+}
+
+// Authenticate ...
+func (a *APIKeyAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	key := r.Header.Get(APIKeyHeader)
+	if key == "" {
+		return nil, nil
+	}
+	for _, k := range a.keys {
+		if subtle.ConstantTimeCompare(k.key, []byte(key)) == 1 {
+			return k.principal, nil
+		}
+	}
+	return nil, fmt.Errorf("unknown api key")
+}
@@ -0,0 +1,132 @@
+package auth
+
+import (
+	"errors"
+	logs "gglogs"
+	"manageChain/protocols"
+	"net/http"
+
+	"github.com/astaxie/beego"
+	"github.com/astaxie/beego/context"
+)
+
+var logger *logs.BeeLogger
+
+func init() {
+	logger = logs.GetBeeLogger()
+}
+
+// AllOrgs binds a credential to every org
+const AllOrgs = "*"
+
+const principalKey = "principal"
+
+// Principal is the authenticated caller and the orgs it may act as
+type Principal struct {
+	Name string
+	Orgs []string
+}
+
+// Anonymous is the principal of every request when authentication is disabled
+var Anonymous = &Principal{Name: "anonymous", Orgs: []string{AllOrgs}}
+
+// CanActAs ...
+func (p *Principal) CanActAs(org string) bool {
+	if p == nil {
+		return false
+	}
+	for _, o := range p.Orgs {
+		if o == AllOrgs || o == org {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize fails unless p may act as all of orgs
+func (p *Principal) Authorize(orgs ...string) error {
+	for _, org := range orgs {
+		if !p.CanActAs(org) {
+			name := ""
+			if p != nil {
+				name = p.Name
+			}
+			return protocols.Errorf(protocols.CodeForbidden, "%s is not allowed to act as org %s", name, org)
+		}
+	}
+	return nil
+}
+
+// Authenticator verifies the credential of a request, it returns nil
+// without error when the request carries no credential it understands
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// FromConfig builds the authenticators configured in the auth section
+// of app.conf, nil means authentication is disabled. Once enabled, a bad
+// credential or no credential at all is an error so the api never falls
+// back to Anonymous
+func FromConfig() ([]Authenticator, error) {
+	enable, err := beego.AppConfig.Bool("auth::Enable")
+	if err != nil && beego.AppConfig.String("auth::Enable") != "" {
+		return nil, err
+	}
+	if !enable {
+		logger.Warn("authentication is disabled, every caller may act as any org")
+		return nil, nil
+	}
+
+	authenticators := []Authenticator{}
+	if keys := beego.AppConfig.Strings("auth::APIKeys"); len(keys) > 0 {
+		a, err := NewAPIKeyAuthenticator(keys)
+		if err != nil {
+			return nil, err
+		}
+		authenticators = append(authenticators, a)
+	}
+	if secret := beego.AppConfig.String("auth::JWTSecret"); secret != "" {
+		authenticators = append(authenticators, NewJWTAuthenticator([]byte(secret)))
+	}
+	if len(authenticators) == 0 {
+		return nil, errors.New("authentication is enabled but neither APIKeys nor JWTSecret is configured")
+	}
+	return authenticators, nil
+}
+
+// NewFilter returns the filter authenticating every request, the principal
+// is then given by FromContext. Nil authenticators serve every request as
+// Anonymous, an empty list rejects every request
+func NewFilter(authenticators []Authenticator) beego.FilterFunc {
+	return func(ctx *context.Context) {
+		if authenticators == nil {
+			ctx.Input.SetData(principalKey, Anonymous)
+			return
+		}
+		for _, a := range authenticators {
+			p, err := a.Authenticate(ctx.Request)
+			if err != nil {
+				logger.Error("Error authenticating %s: %s", ctx.Input.IP(), err)
+				abort(ctx, protocols.WrapError(protocols.CodeUnauthorized, err))
+				return
+			}
+			if p != nil {
+				ctx.Input.SetData(principalKey, p)
+				return
+			}
+		}
+		abort(ctx, protocols.Errorf(protocols.CodeUnauthorized, "missing credential"))
+	}
+}
+
+// FromContext returns the principal set by the filter, nil if none
+func FromContext(ctx *context.Context) *Principal {
+	p, _ := ctx.Input.GetData(principalKey).(*Principal)
+	return p
+}
+
+func abort(ctx *context.Context, err error) {
+	status, msg := protocols.ToMessage(err)
+	ctx.Output.SetStatus(status)
+	ctx.Output.JSON(msg, false, false)
+}
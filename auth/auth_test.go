@@ -0,0 +1,68 @@
+package auth
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestAPIKey(t *testing.T) {
+	a, err := NewAPIKeyAuthenticator([]string{"ops:secret:testorg1,testorg2", "root:toor:*"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ := http.NewRequest("GET", "/channel/mychannel/info", nil)
+	if p, err := a.Authenticate(r); p != nil || err != nil {
+		t.Fatalf("expected no principal without key, got %v %v", p, err)
+	}
+
+	r.Header.Set(APIKeyHeader, "secret")
+	p, err := a.Authenticate(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !p.CanActAs("testorg2") || p.Authorize("testorg1", "testorg3") == nil {
+		t.Fatalf("unexpected orgs of %+v", p)
+	}
+
+	r.Header.Set(APIKeyHeader, "wrong")
+	if _, err := a.Authenticate(r); err == nil {
+		t.Fatal("expected error for unknown key")
+	}
+
+	if _, err := NewAPIKeyAuthenticator([]string{"ops:secret"}); err == nil {
+		t.Fatal("expected error for entry without orgs")
+	}
+}
+
+func TestJWT(t *testing.T) {
+	a := NewJWTAuthenticator([]byte("secret"))
+	token, err := a.Sign(&Claims{
+		Subject:   "robot",
+		Orgs:      []string{"testorg1"},
+		ExpiresAt: time.Now().Add(time.Hour).Unix(),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r, _ := http.NewRequest("GET", "/jobs", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	p, err := a.Authenticate(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.Name != "robot" || !p.CanActAs("testorg1") || p.CanActAs("testorg2") {
+		t.Fatalf("unexpected principal %+v", p)
+	}
+
+	if _, err := NewJWTAuthenticator([]byte("other")).Verify(token); err == nil {
+		t.Fatal("expected error for token signed by another secret")
+	}
+
+	a.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	if _, err := a.Verify(token); err == nil {
+		t.Fatal("expected error for expired token")
+	}
+}
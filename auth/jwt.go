@@ -0,0 +1,124 @@
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Claims of the tokens, Orgs binds the token to the orgs it may act as
+type Claims struct {
+	Subject   string   `json:"sub"`
+	Orgs      []string `json:"orgs"`
+	ExpiresAt int64    `json:"exp,omitempty"`
+	NotBefore int64    `json:"nbf,omitempty"`
+}
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Typ string `json:"typ,omitempty"`
+}
+
+// JWTAuthenticator verifies HS256 bearer tokens signed with a shared secret
+type JWTAuthenticator struct {
+	secret []byte
+	now    func() time.Time
+}
+
+// NewJWTAuthenticator ...
+func NewJWTAuthenticator(secret []byte) *JWTAuthenticator {
+	return &JWTAuthenticator{
+		secret: secret,
+		now:    time.Now,
+	}
+}
+
+// Authenticate ...
+func (a *JWTAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	authz := r.Header.Get("Authorization")
+	if !strings.HasPrefix(authz, "Bearer ") {
+		return nil, nil
+	}
+	claims, err := a.Verify(strings.TrimPrefix(authz, "Bearer "))
+	if err != nil {
+		return nil, err
+	}
+	return &Principal{Name: claims.Subject, Orgs: claims.Orgs}, nil
+}
+
+// Sign returns a token for claims, used by operators to issue tokens
+func (a *JWTAuthenticator) Sign(claims *Claims) (string, error) {
+	header, err := json.Marshal(&jwtHeader{Alg: "HS256", Typ: "JWT"})
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	signing := encode(header) + "." + encode(payload)
+	return signing + "." + encode(a.mac(signing)), nil
+}
+
+// Verify checks the signature and the validity period of token
+func (a *JWTAuthenticator) Verify(token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errors.New("malformed token")
+	}
+
+	header := &jwtHeader{}
+	if err := decode(parts[0], header); err != nil {
+		return nil, err
+	}
+	if header.Alg != "HS256" {
+		return nil, errors.New("unsupported token algorithm " + header.Alg)
+	}
+
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(sig, a.mac(parts[0]+"."+parts[1])) {
+		return nil, errors.New("bad token signature")
+	}
+
+	claims := &Claims{}
+	if err := decode(parts[1], claims); err != nil {
+		return nil, err
+	}
+	now := a.now().Unix()
+	if claims.ExpiresAt != 0 && now >= claims.ExpiresAt {
+		return nil, errors.New("token is expired")
+	}
+	if claims.NotBefore != 0 && now < claims.NotBefore {
+		return nil, errors.New("token is not valid yet")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("token has no subject")
+	}
+	return claims, nil
+}
+
+func (a *JWTAuthenticator) mac(signing string) []byte {
+	h := hmac.New(sha256.New, a.secret)
+	h.Write([]byte(signing))
+	return h.Sum(nil)
+}
+
+func encode(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decode(part string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(part)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
@@ -1,9 +1,12 @@
 package chaincode
 
 import (
+	"encoding/json"
 	"errors"
 	logs "gglogs"
 	"io/ioutil"
+	"manageChain/protocols"
+	"unicode/utf8"
 
 	pp "github.com/hyperledger/fabric/protos/peer"
 	"github.com/hyperledger/fabric/sdk"
@@ -24,6 +27,7 @@ type Chaincode struct {
 	ccVersion string
 	orgCA     *sdk.CA
 	client    *sdk.Client
+	onStep    func(name string)
 }
 
 func NewChaincode(orgMSP string, ccTarPath string, ccPath string, ccName string, ccVersion string, orgCA *sdk.CA, gm bool) (*Chaincode, error) {
@@ -44,15 +48,27 @@ func NewChaincode(orgMSP string, ccTarPath string, ccPath string, ccName string,
 	}, nil
 }
 
+// OnStep sets the callback reporting the progress of long-running operations
+func (cc *Chaincode) OnStep(f func(name string)) {
+	cc.onStep = f
+}
+
+func (cc *Chaincode) step(name string) {
+	if cc.onStep != nil {
+		cc.onStep(name)
+	}
+}
+
 func (cc *Chaincode) InstallChaincode(endorsers []*sdk.Endpoint) error {
 	ccTarPath := cc.ccTarPath
 	ccPath := cc.ccPath
 	ccName := cc.ccName
 	ccVersion := cc.ccVersion
 	if ccTarPath == "" {
-		return errors.New("chaincode package path should not be empty")
+		return protocols.Errorf(protocols.CodeBadRequest, "chaincode package path should not be empty")
 	}
 
+	cc.step("install chaincode")
 	err := installChaincode(cc.client, endorsers, ccTarPath, ccPath, ccName, ccVersion)
 	if err != nil {
 		logger.Error("Error installing  chaincode", err)
@@ -79,6 +95,7 @@ func (cc *Chaincode) InstantiateChaincode(endorsers []*sdk.Endpoint, casters []*
 	ccName := cc.ccName
 	ccVersion := cc.ccVersion
 
+	cc.step("instantiate chaincode")
 	err := instantiateChaincode(cc.client, channelName, ccName, ccVersion, endorsers, casters, args, policy)
 	if err != nil {
 		logger.Error("Error Instantiate chaincode", err)
@@ -90,81 +107,205 @@ func (cc *Chaincode) InstantiateChaincode(endorsers []*sdk.Endpoint, casters []*
 
 func instantiateChaincode(client *sdk.Client, chainID string, ccName string, version string, endorsers []*sdk.Endpoint, casters []*sdk.Endpoint, args [][]byte, policy string) error {
 	logger.Info("policy:%s\n\n", policy)
+	failed := protocols.Errorf(protocols.CodeEndorsementFailed, "failed Instantiate chaincode")
 	for _, endorser := range endorsers {
 		if err := client.InstantiateChaincode(chainID, ccName, version, args, policy, nil, endorser, casters); err != nil {
 			logger.Error("Error Instantiate chaincode", err)
+			failed.AddDetail(endorser.Address, err)
+			continue
+		}
+		return nil
+	}
+	return failed
+}
+
+func (cc *Chaincode) UpgradeChaincode(endorsers []*sdk.Endpoint, casters []*sdk.Endpoint, channelName string, policy string, collection []byte, args [][]byte) error {
+	ccName := cc.ccName
+	ccVersion := cc.ccVersion
+	if ccVersion == "" {
+		return protocols.Errorf(protocols.CodeBadRequest, "chaincode version should not be empty")
+	}
+
+	cc.step("check installed chaincode")
+	err := checkInstalled(cc.client, endorsers, ccName, ccVersion)
+	if err != nil {
+		logger.Error("Error checking installed chaincode", err)
+		return err
+	}
+
+	cc.step("upgrade chaincode")
+	err = upgradeChaincode(cc.client, channelName, ccName, ccVersion, endorsers, casters, args, policy, collection)
+	if err != nil {
+		logger.Error("Error Upgrade chaincode", err)
+		return err
+	}
+	logger.Info("Successfully Upgrade  chaincode")
+	return nil
+}
+
+func upgradeChaincode(client *sdk.Client, chainID string, ccName string, version string, endorsers []*sdk.Endpoint, casters []*sdk.Endpoint, args [][]byte, policy string, collection []byte) error {
+	failed := protocols.Errorf(protocols.CodeEndorsementFailed, "failed Upgrade chaincode")
+	for _, endorser := range endorsers {
+		if err := client.UpgradeChaincode(chainID, ccName, version, args, policy, collection, endorser, casters); err != nil {
+			logger.Error("Error Upgrade chaincode", err)
+			failed.AddDetail(endorser.Address, err)
 			continue
 		}
 		return nil
 	}
-	return errors.New("failed Instantiate chaincode")
+	return failed
+}
+
+func (cc *Chaincode) InstalledChaincodes(peers []*sdk.Endpoint) *ChaincodeListResponse {
+	return listChaincodes(peers, func(peer *sdk.Endpoint) ([]*pp.ChaincodeInfo, error) {
+		return installedChaincodes(cc.client, peer)
+	})
+}
+
+func (cc *Chaincode) InstantiatedChaincodes(channelName string, peers []*sdk.Endpoint) (*ChaincodeListResponse, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	return listChaincodes(peers, func(peer *sdk.Endpoint) ([]*pp.ChaincodeInfo, error) {
+		return instantiatedChaincodes(cc.client, channelName, peer)
+	}), nil
 }
 
-func (cc *Chaincode) Invoke(channelName string, peers []*sdk.Endpoint, orderers []*sdk.Endpoint, args [][]byte) error {
+func (cc *Chaincode) Invoke(channelName string, peers []*sdk.Endpoint, orderers []*sdk.Endpoint, args [][]byte) (*InvokeResponse, error) {
 	client := cc.client
 	ccName := cc.ccName
 
-	err := invoke(client, channelName, ccName, args, peers, orderers)
+	resp, err := invoke(client, channelName, ccName, args, peers, orderers)
 	if err != nil {
 		logger.Error("Error invoke chaincode", err)
-		return err
+		return nil, err
 	}
 	logger.Info("Successfully invoke  chaincode")
 
-	return nil
+	return resp, nil
 }
 
-func invoke(client *sdk.Client, chainID string, chaincode string, args [][]byte, peers []*sdk.Endpoint, orderers []*sdk.Endpoint) error {
+func invoke(client *sdk.Client, chainID string, chaincode string, args [][]byte, peers []*sdk.Endpoint, orderers []*sdk.Endpoint) (*InvokeResponse, error) {
 	txID, prop, resps, endorder, err := endorseOneOfList(client, chainID, chaincode, args, nil, peers)
 	if err != nil {
 		logger.Error("Error endorsing", err)
-		return err
+		return nil, err
 	}
 
 	err = broadcastOneOfList(client, prop, resps, orderers)
 	if err != nil {
 		logger.Error("Error broadcasing", err)
-		return err
+		return nil, err
 	}
 
-	valid, err := client.WaitTx(chainID, txID, endorder, WaitTxTimeout)
+	status, err := client.WaitTxStatus(chainID, txID, endorder, WaitTxTimeout)
+	if err == sdk.ErrClosed {
+		return nil, protocols.Errorf(protocols.CodeTimeout, "timeout waiting transaction %s on %s", txID, endorder.Address)
+	}
 	if err != nil {
 		logger.Error("Error waiting transaction", err)
-		return err
+		return nil, err
 	}
 
-	if !valid {
-		return errors.New("invoke is not valid, please try again")
+	if status.ValidationCode != pp.TxValidationCode_VALID {
+		invalid := protocols.Errorf(protocols.CodeTxInvalid, "invoke %s is not valid, please try again", txID)
+		invalid.ValidationCode = status.ValidationCode.String()
+		return nil, invalid
 	}
 
-	return nil
+	resp := &InvokeResponse{
+		TxID:           txID,
+		Endorser:       endorder.Address,
+		BlockNumber:    status.BlockNumber,
+		ValidationCode: status.ValidationCode.String(),
+	}
+	if len(resps) > 0 && resps[0].Response != nil {
+		resp.Payload = resps[0].Response.Payload
+		resp.PayloadString, resp.PayloadJSON = decodePayload(resp.Payload)
+	}
+	return resp, nil
+}
+
+// decodePayload returns the payload as string if it is valid UTF-8,
+// and as raw JSON if it parses
+func decodePayload(payload []byte) (string, json.RawMessage) {
+	var str string
+	var raw json.RawMessage
+	if utf8.Valid(payload) {
+		str = string(payload)
+	}
+	if len(payload) > 0 && json.Valid(payload) {
+		raw = json.RawMessage(payload)
+	}
+	return str, raw
+}
+
+func (cc *Chaincode) Query(channelName string, peers []*sdk.Endpoint, args [][]byte) ([]*QueryResponse, error) {
+	client := cc.client
+	ccName := cc.ccName
+
+	resps, err := query(client, channelName, ccName, args, peers)
+	if err != nil {
+		logger.Error("Error query chaincode", err)
+		return nil, err
+	}
+	logger.Info("Successfully query  chaincode")
+
+	return resps, nil
+}
+
+// query endorses without ordering, so the ledger is never changed
+func query(client *sdk.Client, chainID string, chaincode string, args [][]byte, peers []*sdk.Endpoint) ([]*QueryResponse, error) {
+	_, _, resps, endorser, err := endorseOneOfList(client, chainID, chaincode, args, nil, peers)
+	if err != nil {
+		logger.Error("Error endorsing", err)
+		return nil, err
+	}
+
+	var ret []*QueryResponse
+	for _, resp := range resps {
+		if resp.Response == nil {
+			return nil, errors.New("got nil response from " + endorser.Address)
+		}
+		ret = append(ret, &QueryResponse{
+			Endorser: endorser.Address,
+			Status:   resp.Response.Status,
+			Message:  resp.Response.Message,
+			Payload:  string(resp.Response.Payload),
+		})
+	}
+	return ret, nil
 }
 
 func endorseOneOfList(client *sdk.Client, chainID string, chaincode string, args [][]byte, transient map[string][]byte, peerEndpoints []*sdk.Endpoint) (txID string, prop *pp.Proposal, resps []*pp.ProposalResponse, endorser *sdk.Endpoint, err error) {
+	if len(peerEndpoints) == 0 {
+		return "", nil, nil, nil, protocols.Errorf(protocols.CodeBadRequest, "no peers can be found")
+	}
+	failed := protocols.Errorf(protocols.CodeEndorsementFailed, "failed proposing through all peers")
 	for _, peer := range peerEndpoints {
 		txID, prop, resps, err = client.Endorse(chainID, chaincode, args, transient, []*sdk.Endpoint{peer})
 		if err == nil {
 			endorser = peer
-			break
+			return
 		}
 		logger.Error("Error endorsing", err)
+		failed.AddDetail(peer.Address, err)
 	}
-	if err != nil {
-		return "", nil, nil, nil, errors.New("failed proposing through all peers")
-	}
-	return
+	return "", nil, nil, nil, failed
 }
 
 func broadcastOneOfList(client *sdk.Client, prop *pp.Proposal, resps []*pp.ProposalResponse, ordererEndpoints []*sdk.Endpoint) (err error) {
+	if len(ordererEndpoints) == 0 {
+		return protocols.Errorf(protocols.CodeBadRequest, "no orderers can be found")
+	}
+	failed := protocols.Errorf(protocols.CodeBroadcastFailed, "failed broadcasting through all orderers")
 	for _, orderer := range ordererEndpoints {
 		err = client.Broadcast(prop, resps, orderer)
 		if err == nil {
-			break
+			return nil
 		}
 		logger.Error("Error broadcasting", err)
+		failed.AddDetail(orderer.Address, err)
 	}
-	if err != nil {
-		return errors.New("failed broadcasting through all orderers")
-	}
-	return
+	return failed
 }
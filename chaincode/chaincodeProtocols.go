@@ -1,12 +1,14 @@
 package chaincode
 
 import (
+	"encoding/json"
 	"time"
 )
 
 const (
 	InstallChaincodeTimeout     = 5 * time.Second
 	InstantiateChaincodeTimeout = 5 * time.Second
+	QueryChaincodeTimeout       = 5 * time.Second
 	WaitTxTimeout               = 20 * time.Second
 )
 const (
@@ -32,6 +34,18 @@ type InstantiateChaincodeRequest struct {
 	OrdererNodes []*ServiceNode
 }
 
+type UpgradeChaincodeRequest struct {
+	Org              string
+	ChannelName      string
+	CcName           string
+	CcVersion        string
+	Policy           string
+	CollectionConfig string
+	Args             [][]byte
+	PeerNodes        []*ServiceNode
+	OrdererNodes     []*ServiceNode
+}
+
 type InvokeRequest struct {
 	Org          string
 	ChannelName  string
@@ -41,6 +55,67 @@ type InvokeRequest struct {
 	OrdererNodes []*ServiceNode
 }
 
+type QueryRequest struct {
+	Org         string
+	ChannelName string
+	CcName      string
+	Args        [][]byte
+	PeerNodes   []*ServiceNode
+}
+
+// QueryResponse is the chaincode response returned by one endorser
+type QueryResponse struct {
+	Endorser string
+	Status   int32
+	Message  string
+	Payload  string
+}
+
+// InvokeResponse describes an ordered transaction and its chaincode response.
+// PayloadString is set when the payload is valid UTF-8, and PayloadJSON
+// when it is valid JSON.
+type InvokeResponse struct {
+	TxID           string
+	Endorser       string
+	Payload        []byte
+	PayloadString  string
+	PayloadJSON    json.RawMessage
+	BlockNumber    uint64
+	ValidationCode string
+}
+
+type ListChaincodeRequest struct {
+	Org         string
+	ChannelName string
+	PeerNodes   []*ServiceNode
+}
+
+// ChaincodeInfo is the lscc view of one chaincode, ID is the hex encoded hash of the deployment spec
+type ChaincodeInfo struct {
+	Name    string
+	Version string
+	Path    string
+	Input   string
+	Escc    string
+	Vscc    string
+	ID      string
+}
+
+// PeerChaincodes lists the chaincodes of one peer, Missing holds the name:version
+// pairs found on other peers but not on this one
+type PeerChaincodes struct {
+	Peer       string
+	Chaincodes []*ChaincodeInfo
+	Mismatch   bool
+	Missing    []string
+	Error      string
+}
+
+type ChaincodeListResponse struct {
+	Peers      []*PeerChaincodes
+	Consistent bool
+}
+
 type ServiceNode struct {
 	ID               string
 	Endpoint         string
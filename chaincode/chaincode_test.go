@@ -124,6 +124,73 @@ func TestInstantiateChaincode(t *testing.T) {
 	t.Log(string(ret))
 }
 
+func TestUpgradeChaincode(t *testing.T) {
+	org := "testorg1"
+	channelName := "channel1"
+	ccName := "mycc"
+	ccVersion := "1.1"
+	policy := acceptAllPolicy
+	args := [][]byte{
+		[]byte("init"),
+		[]byte("a"),
+		[]byte("1000000"),
+		[]byte("b"),
+		[]byte("1000000"),
+	}
+	peernodes := []*ServiceNode{
+		&ServiceNode{
+			ID:               "peer0",
+			Endpoint:         "172.16.93.215:56051",
+			ExternalEndpoint: "172.16.93.215:56051",
+			Public:           true,
+		},
+		&ServiceNode{
+			ID:               "peer1",
+			Endpoint:         "172.16.93.215:56151",
+			ExternalEndpoint: "172.16.93.215:56151",
+			Public:           true,
+		},
+	}
+
+	ordernodes := []*ServiceNode{
+		&ServiceNode{
+			ID:               "orderer0",
+			Endpoint:         "172.16.93.215:56050",
+			ExternalEndpoint: "172.16.93.215:56050",
+			Public:           true,
+		},
+	}
+
+	icr := &UpgradeChaincodeRequest{
+		Org:          org,
+		CcName:       ccName,
+		CcVersion:    ccVersion,
+		Policy:       policy,
+		Args:         args,
+		ChannelName:  channelName,
+		PeerNodes:    peernodes,
+		OrdererNodes: ordernodes,
+	}
+
+	data, err := json.Marshal(icr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrt := bytes.NewBuffer(data)
+
+	resp, err := http.Post("http://127.0.0.1:8080/chaincode/upgrade", "application/json", wrt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log(string(ret))
+}
+
 func TestMoveInvoke(t *testing.T) {
 	org := "testorg1"
 	channelName := "channel1"
@@ -249,3 +316,96 @@ func TestQueryInvoke(t *testing.T) {
 
 	t.Log(string(ret))
 }
+
+func TestQueryChaincode(t *testing.T) {
+	org := "testorg1"
+	channelName := "channel1"
+	ccName := "mycc"
+	args := [][]byte{
+		[]byte("invoke"),
+		[]byte("query"),
+		[]byte("a"),
+	}
+	peernodes := []*ServiceNode{
+		&ServiceNode{
+			ID:               "peer0",
+			Endpoint:         "172.16.93.215:56051",
+			ExternalEndpoint: "172.16.93.215:56051",
+			Public:           true,
+		},
+		&ServiceNode{
+			ID:               "peer1",
+			Endpoint:         "172.16.93.215:56151",
+			ExternalEndpoint: "172.16.93.215:56151",
+			Public:           true,
+		},
+	}
+
+	qr := &QueryRequest{
+		Org:         org,
+		CcName:      ccName,
+		ChannelName: channelName,
+		Args:        args,
+		PeerNodes:   peernodes,
+	}
+
+	data, err := json.Marshal(qr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrt := bytes.NewBuffer(data)
+
+	resp, err := http.Post("http://127.0.0.1:8080/chaincode/query", "application/json", wrt)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ret, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Log(string(ret))
+}
+
+func TestInstalledChaincodes(t *testing.T) {
+	peernodes := []*ServiceNode{
+		&ServiceNode{
+			ID:               "peer0",
+			Endpoint:         "172.16.93.215:56051",
+			ExternalEndpoint: "172.16.93.215:56051",
+			Public:           true,
+		},
+		&ServiceNode{
+			ID:               "peer1",
+			Endpoint:         "172.16.93.215:56151",
+			ExternalEndpoint: "172.16.93.215:56151",
+			Public:           true,
+		},
+	}
+
+	lcr := &ListChaincodeRequest{
+		Org:         "testorg1",
+		ChannelName: "channel1",
+		PeerNodes:   peernodes,
+	}
+
+	data, err := json.Marshal(lcr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, url := range []string{"http://127.0.0.1:8080/chaincode/installed", "http://127.0.0.1:8080/chaincode/instantiated"} {
+		resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ret, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		t.Log(string(ret))
+	}
+}
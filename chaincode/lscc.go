@@ -0,0 +1,134 @@
+package chaincode
+
+import (
+	"encoding/hex"
+	"fmt"
+	"sort"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	pp "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+const (
+	lsccName = "lscc"
+
+	getInstalledChaincodes = "getinstalledchaincodes"
+	getChaincodes          = "getchaincodes"
+)
+
+// installedChaincodes queries lscc for the chaincodes installed on the peer
+func installedChaincodes(client *sdk.Client, peer *sdk.Endpoint) ([]*pp.ChaincodeInfo, error) {
+	args := [][]byte{[]byte(getInstalledChaincodes)}
+	return queryLSCC(client, "", args, peer)
+}
+
+// instantiatedChaincodes queries lscc for the chaincodes instantiated on the channel
+func instantiatedChaincodes(client *sdk.Client, chainID string, peer *sdk.Endpoint) ([]*pp.ChaincodeInfo, error) {
+	args := [][]byte{[]byte(getChaincodes)}
+	return queryLSCC(client, chainID, args, peer)
+}
+
+func queryLSCC(client *sdk.Client, chainID string, args [][]byte, peer *sdk.Endpoint) ([]*pp.ChaincodeInfo, error) {
+	_, _, resps, err := client.Endorse(chainID, lsccName, args, nil, []*sdk.Endpoint{peer})
+	if err != nil {
+		logger.Error("Error querying lscc", err)
+		return nil, err
+	}
+	if len(resps) == 0 || resps[0].Response == nil {
+		return nil, fmt.Errorf("no lscc response from %s", peer.Address)
+	}
+	resp := resps[0].Response
+	if resp.Status != shim.OK {
+		return nil, fmt.Errorf("bad lscc response from %s, status: %d, message: %s", peer.Address, resp.Status, resp.Message)
+	}
+
+	cqr := &pp.ChaincodeQueryResponse{}
+	if err := proto.Unmarshal(resp.Payload, cqr); err != nil {
+		logger.Error("Error unmarshaling ChaincodeQueryResponse", err)
+		return nil, err
+	}
+	return cqr.Chaincodes, nil
+}
+
+// checkInstalled makes sure the given version of chaincode is installed on every peer
+func checkInstalled(client *sdk.Client, peers []*sdk.Endpoint, name string, version string) error {
+	for _, peer := range peers {
+		ccs, err := installedChaincodes(client, peer)
+		if err != nil {
+			return err
+		}
+		installed := false
+		for _, cc := range ccs {
+			if cc.Name == name && cc.Version == version {
+				installed = true
+				break
+			}
+		}
+		if !installed {
+			return fmt.Errorf("chaincode %s:%s is not installed on peer %s", name, version, peer.Address)
+		}
+	}
+	return nil
+}
+
+// listChaincodes collects the chaincodes of every peer with the given lscc query,
+// a peer failing to answer does not stop the others from being listed
+func listChaincodes(peers []*sdk.Endpoint, list func(peer *sdk.Endpoint) ([]*pp.ChaincodeInfo, error)) *ChaincodeListResponse {
+	ret := &ChaincodeListResponse{Consistent: true}
+	for _, peer := range peers {
+		pc := &PeerChaincodes{Peer: peer.Address}
+		ccs, err := list(peer)
+		if err != nil {
+			logger.Error("Error listing chaincodes", err)
+			pc.Error = err.Error()
+		}
+		for _, cc := range ccs {
+			pc.Chaincodes = append(pc.Chaincodes, &ChaincodeInfo{
+				Name:    cc.Name,
+				Version: cc.Version,
+				Path:    cc.Path,
+				Input:   cc.Input,
+				Escc:    cc.Escc,
+				Vscc:    cc.Vscc,
+				ID:      hex.EncodeToString(cc.Id),
+			})
+		}
+		ret.Peers = append(ret.Peers, pc)
+	}
+	markMismatch(ret)
+	return ret
+}
+
+// markMismatch flags the peers whose chaincode versions differ from the others
+func markMismatch(list *ChaincodeListResponse) {
+	all := make(map[string]bool)
+	for _, pc := range list.Peers {
+		if pc.Error != "" {
+			continue
+		}
+		for _, cc := range pc.Chaincodes {
+			all[cc.Name+":"+cc.Version] = true
+		}
+	}
+	for _, pc := range list.Peers {
+		if pc.Error != "" {
+			continue
+		}
+		own := make(map[string]bool)
+		for _, cc := range pc.Chaincodes {
+			own[cc.Name+":"+cc.Version] = true
+		}
+		for key := range all {
+			if !own[key] {
+				pc.Missing = append(pc.Missing, key)
+			}
+		}
+		if len(pc.Missing) > 0 {
+			sort.Strings(pc.Missing)
+			pc.Mismatch = true
+			list.Consistent = false
+		}
+	}
+}
@@ -0,0 +1,82 @@
+package channel
+
+import (
+	"manageChain/protocols"
+	"net/url"
+	"strconv"
+
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const anchorPeerScheme = "grpcs"
+
+// UpdateAnchorPeers rewrites the anchor peers of the first org in
+// channelName, its public peers if anchorPeers is empty. The config update
+// is signed by the admin of the org only, as its Admins policy requires
+func (c *Channel) UpdateAnchorPeers(channelName string, anchorPeers []string, dryRun bool) (*ConfigUpdatePreview, error) {
+	if len(anchorPeers) == 0 {
+		anchorPeers = AnchorPeers(c.orgs[0].PeerNodes)
+	}
+	if len(anchorPeers) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "anchor peers should not be empty")
+	}
+	anchors := &pb.AnchorPeers{}
+	seen := make(map[string]bool)
+	for _, rawURL := range anchorPeers {
+		anchor, err := parseAnchorPeer(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		if seen[rawURL] {
+			continue
+		}
+		seen[rawURL] = true
+		anchors.AnchorPeers = append(anchors.AnchorPeers, anchor)
+	}
+
+	mspID := c.orgs[0].OrgMSP
+	return c.updateConfig(channelName, dryRun, func(config *cb.Config) error {
+		org, err := applicationOrg(config, mspID)
+		if err != nil {
+			return err
+		}
+		return marshalConfigValue(org, channelconfig.AnchorPeersKey, anchors)
+	})
+}
+
+// parseAnchorPeer checks rawURL is grpcs://host:port
+func parseAnchorPeer(rawURL string) (*pb.AnchorPeer, error) {
+	addr, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "invalid anchor peer %s: %s", rawURL, err)
+	}
+	if addr.Scheme != anchorPeerScheme || addr.Hostname() == "" || addr.Path != "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "anchor peer %s should be like %s://host:port", rawURL, anchorPeerScheme)
+	}
+	port, err := strconv.Atoi(addr.Port())
+	if err != nil || port <= 0 || port > 65535 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "invalid port of anchor peer %s", rawURL)
+	}
+	return &pb.AnchorPeer{Host: addr.Hostname(), Port: int32(port)}, nil
+}
+
+// applicationOrg returns the group of the application org whose msp is
+// mspID
+func applicationOrg(config *cb.Config, mspID string) (*cb.ConfigGroup, error) {
+	app, ok := config.ChannelGroup.Groups[channelconfig.ApplicationGroupKey]
+	if !ok {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel has no application orgs")
+	}
+	for _, org := range app.Groups {
+		value, ok := org.Values[channelconfig.MSPKey]
+		if !ok {
+			continue
+		}
+		if decodeConfigValue(channelconfig.MSPKey, value.Value) == mspID {
+			return org, nil
+		}
+	}
+	return nil, protocols.Errorf(protocols.CodeNotFound, "org %s is not a member of the channel", mspID)
+}
@@ -0,0 +1,26 @@
+package channel
+
+import "testing"
+
+func TestParseAnchorPeer(t *testing.T) {
+	anchor, err := parseAnchorPeer("grpcs://172.16.93.215:56051")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if anchor.Host != "172.16.93.215" || anchor.Port != 56051 {
+		t.Fatalf("unexpected anchor peer %v", anchor)
+	}
+
+	for _, rawURL := range []string{
+		"172.16.93.215:56051",
+		"grpc://172.16.93.215:56051",
+		"grpcs://172.16.93.215",
+		"grpcs://:56051",
+		"grpcs://172.16.93.215:70000",
+		"grpcs://172.16.93.215:56051/peer0",
+	} {
+		if _, err := parseAnchorPeer(rawURL); err == nil {
+			t.Errorf("expected error parsing %s", rawURL)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package channel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"manageChain/protocols"
+	"os"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+const (
+	defaultArtifactDir   = "artifacts"
+	genesisBlockFile     = "genesis.block"
+	genesisArtifactFile  = "genesis.json"
+	maxNetworkNameLength = 64
+)
+
+var networkNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// NetworkName returns network if given, or the sorted msps of orgs joined
+// by "-", the network their genesis block is stored for
+func NetworkName(network string, orgs []*OrgInfo) (string, error) {
+	if network == "" {
+		var msps []string
+		for _, org := range orgs {
+			msps = append(msps, org.OrgMSP)
+		}
+		sort.Strings(msps)
+		network = strings.Join(msps, "-")
+	}
+	if len(network) > maxNetworkNameLength || !networkNamePattern.MatchString(network) {
+		return "", protocols.Errorf(protocols.CodeBadRequest, "invalid network name %s", network)
+	}
+	return network, nil
+}
+
+// SaveGenesisBlock stores block as the genesis block of network, with the
+// orgs allowed to download it. The former block of network is only replaced
+// if authorize accepts its orgs
+func SaveGenesisBlock(network string, orgs []string, block *cb.Block, authorize func(orgs ...string) error) (*GenesisArtifact, error) {
+	data, err := proto.Marshal(block)
+	if err != nil {
+		return nil, err
+	}
+	config, err := configFromBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	chainID, err := blockChannelID(block)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	artifact := &GenesisArtifact{
+		Network:    network,
+		ChainID:    chainID,
+		Orgs:       orgs,
+		Sequence:   config.Sequence,
+		Size:       len(data),
+		SHA256:     hex.EncodeToString(sum[:]),
+		CreateTime: time.Now(),
+	}
+	if err := artifacts().save(artifact, data, authorize); err != nil {
+		logger.Error("Error saving genesis block of %s: %s", network, err)
+		return nil, err
+	}
+	return artifact, nil
+}
+
+// GenesisBlock returns the genesis block of network and its bytes, which
+// are checked against the stored checksum
+func GenesisBlock(network string) (*GenesisArtifact, []byte, error) {
+	if _, err := NetworkName(network, nil); err != nil {
+		return nil, nil, err
+	}
+	return artifacts().load(network)
+}
+
+// DecodeGenesisBlock returns the config of the genesis block data
+func DecodeGenesisBlock(data []byte) (*ConfigJSON, error) {
+	block := &cb.Block{}
+	if err := proto.Unmarshal(data, block); err != nil {
+		return nil, err
+	}
+	return GenesisConfig(block)
+}
+
+// GenesisConfig returns the config of the genesis block
+func GenesisConfig(block *cb.Block) (*ConfigJSON, error) {
+	config, err := configFromBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	return encodeConfig(config)
+}
+
+// artifactStore keeps the artifacts of each network in its own dir
+type artifactStore struct {
+	dir  string
+	lock sync.Mutex
+}
+
+var (
+	defaultArtifactStore *artifactStore
+	artifactOnce         sync.Once
+)
+
+// artifacts returns the store of the ArtifactDir of app.conf
+func artifacts() *artifactStore {
+	artifactOnce.Do(func() {
+		defaultArtifactStore = &artifactStore{
+			dir: beego.AppConfig.DefaultString("ArtifactDir", defaultArtifactDir),
+		}
+	})
+	return defaultArtifactStore
+}
+
+// save writes the block under its checksum before the metadata, so a
+// failed or interrupted save leaves the metadata referring to the former
+// block, which is only removed once replaced. An existing artifact is
+// only replaced if authorize accepts its orgs
+func (s *artifactStore) save(artifact *GenesisArtifact, data []byte, authorize func(orgs ...string) error) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	dir := path.Join(s.dir, artifact.Network)
+	var existing *GenesisArtifact
+	meta, err := ioutil.ReadFile(path.Join(dir, genesisArtifactFile))
+	if err == nil {
+		existing = &GenesisArtifact{}
+		if err := json.Unmarshal(meta, existing); err != nil {
+			return err
+		}
+		if err := authorize(existing.Orgs...); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	meta, err = json.Marshal(artifact)
+	if err != nil {
+		return err
+	}
+	block := path.Join(dir, blockFile(artifact.SHA256))
+	if err := writeFile(block, data); err != nil {
+		return err
+	}
+	if err := writeFile(path.Join(dir, genesisArtifactFile), meta); err != nil {
+		if existing == nil || existing.SHA256 != artifact.SHA256 {
+			os.Remove(block)
+		}
+		return err
+	}
+	if existing != nil && existing.SHA256 != artifact.SHA256 {
+		os.Remove(path.Join(dir, blockFile(existing.SHA256)))
+	}
+	os.Remove(path.Join(dir, genesisBlockFile))
+	return nil
+}
+
+func (s *artifactStore) load(network string) (*GenesisArtifact, []byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	dir := path.Join(s.dir, network)
+	meta, err := ioutil.ReadFile(path.Join(dir, genesisArtifactFile))
+	if os.IsNotExist(err) {
+		return nil, nil, protocols.Errorf(protocols.CodeNotFound, "genesis block of %s not found", network)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	artifact := &GenesisArtifact{}
+	if err := json.Unmarshal(meta, artifact); err != nil {
+		return nil, nil, err
+	}
+	data, err := ioutil.ReadFile(path.Join(dir, blockFile(artifact.SHA256)))
+	if os.IsNotExist(err) {
+		// stored before the blocks were named by their checksum
+		data, err = ioutil.ReadFile(path.Join(dir, genesisBlockFile))
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != artifact.SHA256 {
+		return nil, nil, protocols.Errorf(protocols.CodeInternal, "genesis block of %s doesn't match its checksum", network)
+	}
+	return artifact, data, nil
+}
+
+// blockFile is the name of the block whose checksum is sum
+func blockFile(sum string) string {
+	return "genesis-" + sum + ".block"
+}
+
+// writeFile replaces name by data through a temporary file
+func writeFile(name string, data []byte) error {
+	tmp := name + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, name)
+}
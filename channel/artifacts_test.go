@@ -0,0 +1,148 @@
+package channel
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"manageChain/protocols"
+	"os"
+	"path"
+	"testing"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestGenesisArtifacts(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	artifactOnce.Do(func() {
+		defaultArtifactStore = &artifactStore{dir: dir}
+	})
+
+	network, err := NetworkName("", []*OrgInfo{{OrgMSP: "testorg2"}, {OrgMSP: "testorg1"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if network != "testorg1-testorg2" {
+		t.Fatalf("unexpected network %s", network)
+	}
+	if _, err := NetworkName("../testorg1", nil); err == nil {
+		t.Error("expected error for network ../testorg1")
+	}
+
+	payload := &cb.Payload{
+		Header: &cb.Header{
+			ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{
+				Type:      int32(cb.HeaderType_CONFIG),
+				ChannelId: "systemchain",
+			}),
+		},
+		Data: utils.MarshalOrPanic(&cb.ConfigEnvelope{
+			Config: &cb.Config{ChannelGroup: cb.NewConfigGroup()},
+		}),
+	}
+	block := cb.NewBlock(0, nil)
+	block.Data.Data = [][]byte{utils.MarshalOrPanic(&cb.Envelope{Payload: utils.MarshalOrPanic(payload)})}
+
+	authorize := func(orgs ...string) error { return nil }
+	saved, err := SaveGenesisBlock(network, []string{"testorg1", "testorg2"}, block, authorize)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved.ChainID != "systemchain" || saved.SHA256 == "" {
+		t.Fatalf("unexpected artifact %v", saved)
+	}
+	loaded, data, err := GenesisBlock(network)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.SHA256 != saved.SHA256 || len(data) != saved.Size {
+		t.Fatalf("unexpected artifact %v", loaded)
+	}
+	if _, err := DecodeGenesisBlock(data); err != nil {
+		t.Fatal(err)
+	}
+
+	onlyTestorg3 := func(orgs ...string) error {
+		for _, org := range orgs {
+			if org != "testorg3" {
+				return protocols.Errorf(protocols.CodeForbidden, "not allowed to act as org %s", org)
+			}
+		}
+		return nil
+	}
+	if _, err := SaveGenesisBlock(network, []string{"testorg3"}, block, onlyTestorg3); err == nil {
+		t.Error("expected error replacing the genesis block of other orgs")
+	}
+	if loaded, _, err := GenesisBlock(network); err != nil || loaded.Orgs[0] != "testorg1" {
+		t.Fatalf("unexpected artifact %v after forbidden save: %v", loaded, err)
+	}
+	if _, err := SaveGenesisBlock("testorg3", []string{"testorg3"}, block, onlyTestorg3); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := ioutil.WriteFile(path.Join(dir, network, blockFile(saved.SHA256)), data[1:], 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := GenesisBlock(network); err == nil {
+		t.Error("expected checksum mismatch")
+	}
+	if _, _, err := GenesisBlock("testorg4"); err == nil {
+		t.Error("expected genesis block of testorg4 not found")
+	}
+}
+
+func TestGenesisArtifactFailedSave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "artifacts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	store := &artifactStore{dir: dir}
+	authorize := func(orgs ...string) error { return nil }
+	artifact := func(data string) *GenesisArtifact {
+		sum := sha256.Sum256([]byte(data))
+		return &GenesisArtifact{Network: "failednet", Orgs: []string{"testorg1"}, SHA256: hex.EncodeToString(sum[:])}
+	}
+
+	first := artifact("first")
+	if err := store.save(first, []byte("first"), authorize); err != nil {
+		t.Fatal(err)
+	}
+	// a directory in the way of the temporary metadata file fails its write
+	// once the second block is written
+	if err := os.Mkdir(path.Join(dir, "failednet", genesisArtifactFile+".tmp"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	second := artifact("second")
+	if err := store.save(second, []byte("second"), authorize); err == nil {
+		t.Fatal("expected error writing the metadata")
+	}
+	if _, err := os.Stat(path.Join(dir, "failednet", blockFile(second.SHA256))); !os.IsNotExist(err) {
+		t.Errorf("expected the block of the failed save removed, got %v", err)
+	}
+	loaded, data, err := store.load("failednet")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.SHA256 != first.SHA256 || string(data) != "first" {
+		t.Fatalf("unexpected block %s after failed save", data)
+	}
+
+	if err := os.Remove(path.Join(dir, "failednet", genesisArtifactFile+".tmp")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.save(second, []byte("second"), authorize); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(path.Join(dir, "failednet", blockFile(first.SHA256))); !os.IsNotExist(err) {
+		t.Errorf("expected the replaced block removed, got %v", err)
+	}
+	if _, data, err := store.load("failednet"); err != nil || string(data) != "second" {
+		t.Fatalf("unexpected block %s after save: %v", data, err)
+	}
+}
@@ -0,0 +1,142 @@
+package channel
+
+import (
+	"fmt"
+	"manageChain/protocols"
+	"regexp"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+var capabilityPattern = regexp.MustCompile(`^V(\d+)_(\d+)$`)
+
+// UpgradeCapabilities raises the capability levels of channelNames, the
+// system channel first as the application channels are created from it.
+// Each updated config must pass channelconfig validation, the channels
+// already at the requested levels are skipped. It stops at the first
+// channel failing and returns the previews of the channels upgraded before
+func (c *Channel) UpgradeCapabilities(channelNames []string, caps *Capabilities, dryRun bool) ([]*ConfigUpdatePreview, error) {
+	if len(channelNames) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel names should not be empty")
+	}
+	if caps == nil || caps.Channel == "" && caps.Orderer == "" && caps.Application == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "capabilities should not be empty")
+	}
+	if err := caps.validate(); err != nil {
+		return nil, err
+	}
+
+	return c.updateConfigs(channelNames, dryRun, func(channelName string, config *cb.Config) error {
+		original := proto.Clone(config).(*cb.Config)
+		if err := caps.apply(config); err != nil {
+			return err
+		}
+		if proto.Equal(original, config) {
+			return errConfigUnchanged
+		}
+		return validateConfig(channelName, original, config)
+	})
+}
+
+func (caps *Capabilities) validate() error {
+	for _, level := range []string{caps.Channel, caps.Orderer, caps.Application} {
+		if level != "" && !capabilityPattern.MatchString(level) {
+			return protocols.Errorf(protocols.CodeBadRequest, "invalid capability %s, should be like V1_2", level)
+		}
+	}
+	return nil
+}
+
+// apply sets the non empty levels of caps in config, the application one
+// only if config has an application group
+func (caps *Capabilities) apply(config *cb.Config) error {
+	if caps.Channel != "" {
+		if err := upgradeCapability(config.ChannelGroup, caps.Channel); err != nil {
+			return err
+		}
+	}
+	if caps.Orderer != "" {
+		orderer, ok := config.ChannelGroup.Groups[channelconfig.OrdererGroupKey]
+		if !ok {
+			return protocols.Errorf(protocols.CodeBadRequest, "config has no orderer group")
+		}
+		if err := upgradeCapability(orderer, caps.Orderer); err != nil {
+			return err
+		}
+	}
+	if caps.Application != "" {
+		if application, ok := config.ChannelGroup.Groups[channelconfig.ApplicationGroupKey]; ok {
+			if err := upgradeCapability(application, caps.Application); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// upgradeCapability requires level in group instead of its current levels,
+// which should not be higher. The other capabilities, like
+// V1_1_PVTDATA_EXPERIMENTAL, are kept, and group is left untouched when
+// level is its only one already
+func upgradeCapability(group *cb.ConfigGroup, level string) error {
+	current := &cb.Capabilities{}
+	if _, ok := group.Values[channelconfig.CapabilitiesKey]; ok {
+		if err := unmarshalConfigValue(group, channelconfig.CapabilitiesKey, current); err != nil {
+			return err
+		}
+	}
+	if current.Capabilities == nil {
+		current.Capabilities = make(map[string]*cb.Capability)
+	}
+	var levels []string
+	for name := range current.Capabilities {
+		if !capabilityPattern.MatchString(name) {
+			continue
+		}
+		if compareCapabilities(name, level) > 0 {
+			return protocols.Errorf(protocols.CodeBadRequest, "capability %s is lower than current %s", level, name)
+		}
+		levels = append(levels, name)
+	}
+	if len(levels) == 1 && levels[0] == level {
+		return nil
+	}
+	for _, name := range levels {
+		delete(current.Capabilities, name)
+	}
+	current.Capabilities[level] = &cb.Capability{}
+	return marshalConfigValue(group, channelconfig.CapabilitiesKey, current)
+}
+
+// compareCapabilities compares two levels matching capabilityPattern
+func compareCapabilities(a, b string) int {
+	am, bm := capabilityPattern.FindStringSubmatch(a), capabilityPattern.FindStringSubmatch(b)
+	for i := 1; i < len(am); i++ {
+		x, _ := strconv.Atoi(am[i])
+		y, _ := strconv.Atoi(bm[i])
+		if x != y {
+			return x - y
+		}
+	}
+	return 0
+}
+
+// validateConfig checks updated is a valid config of chainID and a valid
+// successor of original, as the orderer does before accepting it
+func validateConfig(chainID string, original, updated *cb.Config) error {
+	current, err := channelconfig.NewBundle(chainID, original)
+	if err != nil {
+		return err
+	}
+	next, err := channelconfig.NewBundle(chainID, updated)
+	if err != nil {
+		return protocols.WrapError(protocols.CodeBadRequest, fmt.Errorf("invalid config of %s: %s", chainID, err))
+	}
+	if err := current.ValidateNew(next); err != nil {
+		return protocols.WrapError(protocols.CodeBadRequest, fmt.Errorf("invalid config of %s: %s", chainID, err))
+	}
+	return nil
+}
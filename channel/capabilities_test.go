@@ -0,0 +1,59 @@
+package channel
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+func TestUpgradeCapabilities(t *testing.T) {
+	config := &cb.Config{ChannelGroup: cb.NewConfigGroup()}
+	orderer := cb.NewConfigGroup()
+	config.ChannelGroup.Groups[channelconfig.OrdererGroupKey] = orderer
+	if err := marshalConfigValue(orderer, channelconfig.CapabilitiesKey, &cb.Capabilities{
+		Capabilities: map[string]*cb.Capability{"V1_1": {}, "V1_1_PVTDATA_EXPERIMENTAL": {}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	caps := &Capabilities{Channel: "V1_3", Orderer: "V1_4", Application: "V1_2"}
+	if err := caps.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if err := caps.apply(config); err != nil {
+		t.Fatal(err)
+	}
+	upgraded := &cb.Capabilities{}
+	if err := unmarshalConfigValue(orderer, channelconfig.CapabilitiesKey, upgraded); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := upgraded.Capabilities["V1_4"]; !ok || len(upgraded.Capabilities) != 2 {
+		t.Fatalf("unexpected orderer capabilities %v", upgraded)
+	}
+	if _, ok := upgraded.Capabilities["V1_1_PVTDATA_EXPERIMENTAL"]; !ok {
+		t.Fatalf("unexpected orderer capabilities %v", upgraded)
+	}
+	if _, ok := config.ChannelGroup.Values[channelconfig.CapabilitiesKey]; !ok {
+		t.Fatal("expected channel capabilities")
+	}
+	if _, ok := config.ChannelGroup.Groups[channelconfig.ApplicationGroupKey]; ok {
+		t.Fatal("unexpected application group")
+	}
+
+	before := proto.Clone(config).(*cb.Config)
+	if err := caps.apply(config); err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(before, config) {
+		t.Fatal("expected config untouched when already upgraded")
+	}
+
+	if err := (&Capabilities{Orderer: "V1_3"}).apply(config); err == nil {
+		t.Error("expected error downgrading capabilities")
+	}
+	if err := (&Capabilities{Channel: "1.4"}).validate(); err == nil {
+		t.Error("expected error validating capabilities")
+	}
+}
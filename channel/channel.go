@@ -3,8 +3,8 @@ package channel
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
 	logs "gglogs"
+	"manageChain/protocols"
 
 	cb "github.com/hyperledger/fabric/protos/common"
 	"github.com/hyperledger/fabric/sdk"
@@ -17,7 +17,10 @@ func init() {
 }
 
 type Channel struct {
-	orgs []*OrgInfo
+	orgs    []*OrgInfo
+	gm      bool
+	onStep  func(name string)
+	network *NetworkConfig
 }
 
 func NewChannel(orgs []*OrgInfo, gm bool) (*Channel, error) {
@@ -25,7 +28,7 @@ func NewChannel(orgs []*OrgInfo, gm bool) (*Channel, error) {
 		logger.Error("args err")
 		return nil, errors.New("args err")
 	}
-	channel := &Channel{}
+	channel := &Channel{gm: gm}
 	for _, org := range orgs {
 		orgMSP := org.OrgMSP
 		orgCA := org.OrgCA
@@ -42,7 +45,19 @@ func NewChannel(orgs []*OrgInfo, gm bool) (*Channel, error) {
 	return channel, nil
 }
 
-func (c *Channel) CreateChannel(ChainID string) error {
+// CreateChannel creates ChainID with the orgs of c, policies override the
+// default ones of the channel and caps the default application capability
+func (c *Channel) CreateChannel(ChainID string, policies map[string]*PolicyConfig, caps *Capabilities) error {
+	channelPolicies, err := sdkPolicies(policies)
+	if err != nil {
+		return err
+	}
+	if caps == nil {
+		caps = &Capabilities{}
+	}
+	if err := caps.validate(); err != nil {
+		return err
+	}
 
 	var organizations []*sdk.Organization
 	for _, org := range c.orgs {
@@ -60,20 +75,26 @@ func (c *Channel) CreateChannel(ChainID string) error {
 
 	conf := &sdk.ChannelConfig{
 		ChainID:       ChainID,
-		Consortium:    sdk.DefaultConsortium,
+		Consortium:    c.network.consortium(),
 		AdminsPolicy:  sdk.PolicyMajorityAdmins,
 		ReadersPolicy: sdk.PolicyAnyReaders,
 		WritersPolicy: sdk.PolicyAnyWriters,
+		Policies:      channelPolicies,
 		Organizations: organizations,
+
+		ApplicationCapability: caps.Application,
 	}
 
 	//use org1
 	orgCA := c.GetOrgCA()
 	casters := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, orgCA.TLSCACert())
 
+	c.step("create channel")
+	failed := protocols.Errorf(protocols.CodeBroadcastFailed, "failed creating %s chain after try all orderers", ChainID)
 	for _, caster := range casters {
 		if err := c.orgs[0].Client.CreateChannel(conf, caster); err != nil {
 			logger.Error("Error creating channel", err)
+			failed.AddDetail(caster.Address, err)
 			continue
 		} else {
 			logger.Info("Successfully creating channel")
@@ -81,30 +102,49 @@ func (c *Channel) CreateChannel(ChainID string) error {
 		}
 	}
 
-	return errors.New(fmt.Sprintf("failed creating %s chain after try all orderers", ChainID))
+	return failed
 
 }
 
-//use org1
+// OnStep sets the callback reporting the progress of long-running operations
+func (c *Channel) OnStep(f func(name string)) {
+	c.onStep = f
+}
+
+func (c *Channel) step(name string) {
+	if c.onStep != nil {
+		c.onStep(name)
+	}
+}
+
+// use org1
 func (c *Channel) GetOrgCA() *sdk.CA {
 	return c.orgs[0].OrgCA
 }
 
 func (c *Channel) JoinChannel(channelName string) error {
-	var err error
-	var block *cb.Block
-	orgCA := c.GetOrgCA()
-	endorsers := serviceNodesToEndpointList(c.orgs[0].PeerNodes, EndorseTimeout, orgCA.TLSCACert())
-	casters := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, orgCA.TLSCACert())
+	c.step("get genesis block")
+	block, err := c.genesisBlock(channelName)
+	if err != nil {
+		return err
+	}
+	return c.join(channelName, block)
+}
+
+func (c *Channel) genesisBlock(channelName string) (block *cb.Block, err error) {
+	casters := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, c.GetOrgCA().TLSCACert())
 	for _, caster := range casters {
 		if block, err = c.orgs[0].Client.GetBlockByChannel(channelName, 0, caster); err == nil {
-			break
+			return block, nil
 		}
 		logger.Error("Error getting block", err)
 	}
-	if err != nil {
-		return errors.New("failed getting block after try all orderers")
-	}
+	return nil, errors.New("failed getting block after try all orderers")
+}
+
+func (c *Channel) join(channelName string, block *cb.Block) error {
+	endorsers := serviceNodesToEndpointList(c.orgs[0].PeerNodes, EndorseTimeout, c.GetOrgCA().TLSCACert())
+	c.step("join channel")
 	return c.orgs[0].Client.JoinChannel(channelName, block, endorsers)
 }
 
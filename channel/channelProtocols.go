@@ -1,6 +1,7 @@
 package channel
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/hyperledger/fabric/common/tools/configtxgen/encoder"
@@ -11,6 +12,14 @@ const (
 	CreateChannelTimeout = 5 * time.Second
 	EndorseTimeout       = 5 * time.Second
 	waitTxTimeout        = 20 * time.Second
+
+	DefaultInviteCodeTTL = 24 * time.Hour
+)
+
+const (
+	// eventBufferSize is the number of blocks buffered for a slow subscriber
+	// before the deliver stream stops being read
+	eventBufferSize = 16
 )
 const (
 	addOrgInfo    = "AddOrgInfo"
@@ -47,6 +56,35 @@ const (
 	rejectState = "Reject"
 )
 
+// decisions of an invitation
+const (
+	InvitationPending   = "pending"
+	InvitationAccepted  = "accepted"
+	InvitationRejected  = "rejected"
+	InvitationConfirmed = "confirmed"
+)
+
+// kinds of config elements
+const (
+	ConfigKindGroup     = "group"
+	ConfigKindValue     = "value"
+	ConfigKindPolicy    = "policy"
+	ConfigKindModPolicy = "mod_policy"
+)
+
+// actions of a config change
+const (
+	ConfigAdded    = "added"
+	ConfigRemoved  = "removed"
+	ConfigModified = "modified"
+)
+
+// states of a pending update
+const (
+	PendingUpdateCollecting = "collecting"
+	PendingUpdateSubmitted  = "submitted"
+)
+
 type ServiceNode struct {
 	ID               string
 	Endpoint         string
@@ -54,6 +92,9 @@ type ServiceNode struct {
 	Public           bool
 }
 
+// OrgInfo is an org and its nodes, Policies are its own policies put into
+// the genesis block, the admin and member signature policies of its msp by
+// default
 type OrgInfo struct {
 	OrgName      string
 	MspID        string
@@ -62,10 +103,44 @@ type OrgInfo struct {
 	Client       *sdk.Client
 	PeerNodes    []*ServiceNode
 	OrdererNodes []*ServiceNode
+	Policies     map[string]*PolicyConfig `json:",omitempty"`
+}
+
+// PolicyConfig is a policy of a channel or an org. Type is ImplicitMeta
+// with a Rule like "MAJORITY Admins", or Signature with a Rule like
+// "AND('Org1.admin','Org2.admin')"
+type PolicyConfig struct {
+	Type string
+	Rule string
 }
+
+// NewCreateChannelRequest creates ChannelName in the consortium of
+// NetworkConfig, Policies override its default MAJORITY Admins, ANY Writers
+// and ANY Readers. Only the application capability of Capabilities
+// applies, the channel and orderer ones come from the system channel
 type NewCreateChannelRequest struct {
-	Orgs        []*OrgInfo
-	ChannelName string
+	Orgs         []*OrgInfo
+	ChannelName  string
+	Policies     map[string]*PolicyConfig
+	Capabilities Capabilities
+	NetworkConfig
+}
+
+// Capabilities are the capability levels like V1_2 of the channel, its
+// orderers and its application, empty ones are the defaults at creation
+// and left as they are on upgrade
+type Capabilities struct {
+	Channel     string
+	Orderer     string
+	Application string
+}
+
+// CapabilitiesRequest upgrades the capabilities of ChannelNames
+type CapabilitiesRequest struct {
+	Orgs         []*OrgInfo
+	ChannelNames []string
+	Capabilities
+	NetworkConfig
 }
 
 type JoinChannelRequest struct {
@@ -80,6 +155,7 @@ type AddOrgRequest struct {
 	Orgs        []*OrgInfo
 	Identity    []byte
 	ChannelName string
+	NetworkConfig
 }
 
 type DeleteOrgRequest struct {
@@ -87,26 +163,231 @@ type DeleteOrgRequest struct {
 	DelOrg      string
 	DelOrderers []string
 	ChannelName string
+	NetworkConfig
+}
+
+// StartInvitationRequest invites the org of Identity into the channel, the
+// first org is the inviter
+type StartInvitationRequest struct {
+	Orgs        []*OrgInfo
+	ChannelName string
+	Identity    []byte
+	NetworkConfig
+}
+
+// VoteInvitationRequest votes as the first org
+type VoteInvitationRequest struct {
+	Orgs        []*OrgInfo
+	ChannelName string
+	Inviter     string
+	Invitee     string
+	Accept      bool
+	NetworkConfig
 }
 
+// CompleteInvitationRequest adds the invitee of an accepted invitation,
+// the first org broadcasts the config update
+type CompleteInvitationRequest struct {
+	Orgs        []*OrgInfo
+	ChannelName string
+	Inviter     string
+	Invitee     string
+	NetworkConfig
+}
+
+// GenCryptoRequest generates the msps of Orgs, Users are the client
+// identities to issue besides the admin, by org name
 type GenCryptoRequest struct {
-	Orgs []*OrgInfo
+	Orgs  []*OrgInfo
+	Users map[string][]*UserConfig
+}
+
+// UserConfig is a client identity issued as Name@org, its cert carries
+// OUs like "client" or an application role if given
+type UserConfig struct {
+	Name string
+	OUs  []string
+}
+
+// UsersRequest issues Users for the existing msp of Org
+type UsersRequest struct {
+	Org   string
+	Users []*UserConfig
 }
 
+// GenGenesisBlockRequest generates the genesis block of the system
+// channel. OrdererType is solo, kafka with Kafkas brokers by default, or
+// etcdraft with all the OrdererNodes of Orgs as consenters and EtcdRaft
+// options. The application capability of Capabilities doesn't apply. The
+// block is stored for Network, the sorted msps of Orgs joined by "-" if
+// empty. Consortiums are the names of the member orgs of each consortium,
+// all Orgs are members of the consortium of NetworkConfig if empty
 type GenGenesisBlockRequest struct {
-	Orgs   []*OrgInfo
-	Kafkas []string
+	Orgs        []*OrgInfo
+	Network     string
+	OrdererType string
+	Kafkas      []string
+	EtcdRaft    RaftOptions
+	BatchConfig
+	Capabilities Capabilities
+	NetworkConfig
+	Consortiums map[string][]string
+}
+
+// RaftOptions tune the etcdraft orderers, TickInterval is a duration like
+// 500ms and SnapshotIntervalSize is in bytes. Zero options take the fabric
+// defaults
+type RaftOptions struct {
+	TickInterval         string
+	ElectionTick         uint32
+	SnapshotIntervalSize uint32
+}
+
+// NetworkConfig names the system channel of the ordering service and the
+// consortium of the orgs, the SystemChannel and Consortium of app.conf if
+// empty, then systemchain and defaultConsortium
+type NetworkConfig struct {
+	SystemChannel string
+	Consortium    string
+}
+
+// ConsortiumOrgRequest adds the org of Identity to the consortium of
+// NetworkConfig, or deletes DelOrg from it
+type ConsortiumOrgRequest struct {
+	Orgs     []*OrgInfo
+	Identity []byte
+	DelOrg   string
+	NetworkConfig
 }
 
+// GenesisArtifact is a stored genesis block, Config is its decoded config
+// when asked for
+type GenesisArtifact struct {
+	Network    string
+	ChainID    string
+	Orgs       []string
+	Sequence   uint64
+	Size       int
+	SHA256     string
+	CreateTime time.Time
+	Config     *ConfigJSON `json:",omitempty"`
+}
+
+// BatchConfig tunes how the orderers cut blocks. BatchTimeout is a
+// duration like "2s", zero fields keep the current or default values
+type BatchConfig struct {
+	BatchTimeout      string
+	MaxMessageCount   uint32
+	AbsoluteMaxBytes  uint32
+	PreferredMaxBytes uint32
+}
+
+// OrdererConfigRequest changes the batch parameters of ChannelName, and
+// its kafka brokers when KafkaBrokers is given
+type OrdererConfigRequest struct {
+	Orgs         []*OrgInfo
+	ChannelName  string
+	KafkaBrokers []string
+	BatchConfig
+}
+
+// ConsenterRequest adds or removes Orderer, an orderer node of the first
+// org of Orgs, as an etcdraft consenter of the system channel of
+// NetworkConfig and of ChannelNames
+type ConsenterRequest struct {
+	Orgs         []*OrgInfo
+	Orderer      ServiceNode
+	ChannelNames []string
+	NetworkConfig
+}
+
+// InviteCodeRequest issues an invite code signed by the first org, valid
+// for ExpiresIn seconds, DefaultInviteCodeTTL if 0
 type InviteCodeRequest struct {
 	Orgs        []*OrgInfo
 	ChannelName string
+	ExpiresIn   int64
 }
 
+// OrgJoinChannelRequest joins the peers of the first org, InviteCode is
+// the code returned by the inviter as it is. InviterCACerts are the pem
+// root certs of the inviters the first org trusts, learned out of band,
+// the code is refused unless its inviter chains to one of them
 type OrgJoinChannelRequest struct {
-	Orgs       []*OrgInfo
-	InviteCode []byte
+	Orgs           []*OrgInfo
+	InviteCode     json.RawMessage
+	InviterCACerts [][]byte
+}
+
+// PendingUpdateRequest stores a config update of ChannelName to collect
+// signatures for. ConfigUpdate is taken as is if given, otherwise it is
+// computed from Identity (add an org) or DelOrg (delete an org) through
+// the orderers of the first org
+type PendingUpdateRequest struct {
+	Orgs         []*OrgInfo
+	ChannelName  string
+	ConfigUpdate []byte
+	Identity     []byte
+	DelOrg       string
+	DelOrderers  []string
+	NetworkConfig
+}
+
+// PendingSignatureRequest adds a ConfigSignature to a pending update. It
+// is made by the admin of the first org when Signature is empty, otherwise
+// SignatureHeader and Signature come from another instance or an offline
+// signer and Orgs may be empty
+type PendingSignatureRequest struct {
+	Orgs            []*OrgInfo
+	SignatureHeader []byte
+	Signature       []byte
+	NetworkConfig
+}
+
+// ConfigSignRequest signs ConfigUpdate, a marshaled common.ConfigUpdate,
+// as the admin of the first org
+type ConfigSignRequest struct {
+	Orgs         []*OrgInfo
+	ConfigUpdate []byte
+}
+
+// ConfigSignature is the common.ConfigSignature of a config update, as
+// posted to the signatures of a pending update
+type ConfigSignature struct {
+	SignatureHeader []byte
+	Signature       []byte
 }
+
+// PendingSubmitRequest broadcasts a pending update through the first org
+type PendingSubmitRequest struct {
+	Orgs []*OrgInfo
+	NetworkConfig
+}
+
+// ConfigEditRequest proposes Config, the config of the channel edited as
+// returned by GET /channel/{name}/config, the first org computes the update
+type ConfigEditRequest struct {
+	Orgs   []*OrgInfo
+	Config *ConfigJSON
+}
+
+// PoliciesRequest replaces Policies of the application group of
+// ChannelName, or of the application org whose msp is Org if given
+type PoliciesRequest struct {
+	Orgs        []*OrgInfo
+	ChannelName string
+	Org         string
+	Policies    map[string]*PolicyConfig
+}
+
+// AnchorPeersRequest rewrites the anchor peers of the first org, given as
+// grpcs://host:port, its public peers if AnchorPeers is empty
+type AnchorPeersRequest struct {
+	Orgs        []*OrgInfo
+	ChannelName string
+	AnchorPeers []string
+}
+
 type IdentityCode struct {
 	Org          string
 	OrgMSP       []byte
@@ -122,6 +403,90 @@ type ChainOrgInfo struct {
 	ChannelName string
 }
 
+// DiscoveryResponse is the channel view of the discovery service asked through Peer
+type DiscoveryResponse struct {
+	Peer            string
+	MSPs            map[string]*sdk.MSPConfig
+	EndorsementPlan *sdk.EndorsementPlan
+}
+
+type ChainInfo struct {
+	Height            uint64
+	CurrentBlockHash  string
+	PreviousBlockHash string
+}
+
+// BlockInfo is a decoded block, hashes are hex encoded
+type BlockInfo struct {
+	Number       uint64
+	PreviousHash string
+	DataHash     string
+	Transactions []*TransactionInfo
+}
+
+type TransactionInfo struct {
+	TxID           string
+	ChannelID      string
+	Type           string
+	Timestamp      time.Time
+	CreatorMSP     string
+	ValidationCode string
+	Actions        []*ActionInfo
+	// DecodeError tells why the transaction couldn't be decoded
+	DecodeError string `json:",omitempty"`
+}
+
+type ActionInfo struct {
+	Chaincode string
+	Version   string
+	Args      []string
+	Status    int32
+	Message   string
+	Payload   string
+	Event     *ChaincodeEventInfo
+	RWSets    []*NsRWSetInfo
+}
+
+type ChaincodeEventInfo struct {
+	ChaincodeID string
+	TxID        string
+	EventName   string
+	Payload     string
+}
+
+// BlockEvent is pushed to the subscribers of a channel, Block is set for
+// full blocks and Transactions for filtered blocks
+type BlockEvent struct {
+	Number          uint64
+	Block           *BlockInfo
+	Transactions    []*FilteredTransactionInfo
+	ChaincodeEvents []*ChaincodeEventInfo
+}
+
+type FilteredTransactionInfo struct {
+	TxID           string
+	Type           string
+	ValidationCode string
+}
+
+type NsRWSetInfo struct {
+	Namespace string
+	Reads     []*ReadInfo
+	Writes    []*WriteInfo
+}
+
+type ReadInfo struct {
+	Key      string
+	BlockNum uint64
+	TxNum    uint64
+}
+
+type WriteInfo struct {
+	Key      string
+	IsDelete bool
+	Value    string
+}
+
 type bytesList [][]byte
 type ccInvitation struct {
 	Inviter    string `json:"inviter"`
@@ -139,8 +504,151 @@ type ccInvitationSignStatus struct {
 	Accepted  string `json:"accepted"`
 	SignTime  int64  `json:"signTime"`
 }
+
+// InvitationStatus is the tally of an invitation, Required is the number
+// of accepting members satisfying the admins policy of the channel.
+// PendingUpdate adds the invitee once the invitation is accepted
+type InvitationStatus struct {
+	Invitation    *ccInvitation
+	Votes         []*ccInvitationSignStatus
+	Members       int
+	Required      int
+	Accepted      int
+	Rejected      int
+	Decision      string
+	PendingUpdate *PendingUpdateStatus `json:",omitempty"`
+}
+
+// InviteCode lets an org already added to the channel join it. It is
+// signed by InviterIdentity, a member of the channel as of ConfigBlock
 type InviteCode struct {
+	ChannelName         string
 	ChannelGenesisBlock []byte
+	ConfigBlock         []byte
+	Orderers            []string
+	OrdererTLSCACerts   [][]byte
+	Inviter             string
+	InviterIdentity     []byte
+	ExpiresAt           int64
+	Signature           []byte `json:",omitempty"`
+}
+
+// OrgJoinChannelResponse tells the invited org where the orderers of the
+// channel are
+type OrgJoinChannelResponse struct {
+	ChannelName       string
+	Orderers          []string
+	OrdererTLSCACerts [][]byte
+	Peers             []string
+}
+
+// PendingUpdate is a config update waiting for the signatures of the
+// channel members
+type PendingUpdate struct {
+	ID           string
+	ChannelName  string
+	ConfigUpdate []byte
+	// Config is the marshaled config the update was proposed on, the
+	// signatures made elsewhere are verified against it
+	Config     []byte `json:",omitempty"`
+	Signatures []*PendingSignature
+	State      string
+	// Inviter and Invitee are set when the update adds the invitee of an
+	// invitation, which is confirmed once the update is submitted
+	Inviter    string `json:",omitempty"`
+	Invitee    string `json:",omitempty"`
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+// PendingSignature is the ConfigSignature of one msp
+type PendingSignature struct {
+	MspID           string
+	SignatureHeader []byte
+	Signature       []byte
+	SignTime        time.Time
+}
+
+// PendingUpdateStatus tells which msps signed a pending update and which
+// of the policies it requires they satisfy, Ready once they satisfy all
+type PendingUpdateStatus struct {
+	*PendingUpdate
+	SignedBy []string
+	Policies []*PolicyStatus
+	Ready    bool
+}
+
+// PolicyStatus is a policy required by a config update, Satisfied by the
+// signatures collected so far or not
+type PolicyStatus struct {
+	*PolicyRequirement
+	Satisfied bool
+}
+
+// ConfigUpdatePreview is what a config update of ChannelName would do
+// without broadcasting it. ConfigUpdate may be given to
+// ProposeConfigUpdate to collect the signatures of Policies
+type ConfigUpdatePreview struct {
+	ChannelName  string
+	ConfigUpdate []byte `json:",omitempty"`
+	Changes      []*ConfigChange
+	ReadSet      []*ConfigElement
+	WriteSet     []*ConfigElement
+	Policies     []*PolicyRequirement
+}
+
+// ConfigChange is an element of the config at Path which is added,
+// removed or modified, known values are decoded
+type ConfigChange struct {
+	Path   string
+	Kind   string
+	Action string
+	Old    interface{} `json:",omitempty"`
+	New    interface{} `json:",omitempty"`
+}
+
+// ConfigElement is an element of the read or write set of a config update
+type ConfigElement struct {
+	Path      string
+	Kind      string
+	Version   uint64
+	ModPolicy string
+}
+
+// PolicyRequirement is a policy to satisfy for the config update to
+// modify Elements
+type PolicyRequirement struct {
+	Path     string
+	Rule     string
+	Elements []string
+}
+
+// ConfigJSON is a cb.Config in the json of configtxlator, the values of
+// known keys, msps and policies are decoded, other values are base64 of
+// their bytes
+type ConfigJSON struct {
+	Sequence     uint64           `json:"sequence,string"`
+	ChannelGroup *ConfigGroupJSON `json:"channel_group"`
+}
+
+type ConfigGroupJSON struct {
+	Version   uint64                       `json:"version,string"`
+	ModPolicy string                       `json:"mod_policy"`
+	Groups    map[string]*ConfigGroupJSON  `json:"groups"`
+	Values    map[string]*ConfigValueJSON  `json:"values"`
+	Policies  map[string]*ConfigPolicyJSON `json:"policies"`
+}
+
+type ConfigValueJSON struct {
+	Version   uint64          `json:"version,string"`
+	ModPolicy string          `json:"mod_policy"`
+	Value     json.RawMessage `json:"value"`
+}
+
+type ConfigPolicyJSON struct {
+	Version   uint64          `json:"version,string"`
+	ModPolicy string          `json:"mod_policy"`
+	Policy    json.RawMessage `json:"policy"`
 }
 
 const (
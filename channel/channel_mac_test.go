@@ -672,3 +672,295 @@ func TestJoinChannel(t *testing.T) {
 	}
 	t.Log(string(ret))
 }
+
+func TestDiscovery(t *testing.T) {
+	url := "http://127.0.0.1:8080/channel/channel1/discovery?org=testorg1&peer=172.16.93.215:56051&chaincode=mycc"
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(ret))
+}
+
+func TestExplorer(t *testing.T) {
+	urls := []string{
+		"http://127.0.0.1:8080/channel/channel1/info?org=testorg1&peer=172.16.93.215:56051",
+		"http://127.0.0.1:8080/channel/channel1/blocks/1?org=testorg1&peer=172.16.93.215:56051",
+	}
+	for _, url := range urls {
+		resp, err := http.Get(url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(string(ret))
+	}
+}
+
+func TestInvitation(t *testing.T) {
+	id, err := ioutil.ReadFile("newOrgIdentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	org1 := &OrgInfo{
+		OrgName: "testorg1",
+		OrgMSP:  "testorg1",
+		MspID:   "testorg1",
+		PeerNodes: []*ServiceNode{
+			&ServiceNode{
+				ID:               "peer0",
+				Endpoint:         "172.16.93.215:56051",
+				ExternalEndpoint: "172.16.93.215:56051",
+				Public:           true,
+			},
+		},
+		OrdererNodes: []*ServiceNode{
+			&ServiceNode{
+				ID:               "orderer0",
+				Endpoint:         "172.16.93.215:56050",
+				ExternalEndpoint: "172.16.93.215:56050",
+				Public:           true,
+			},
+		},
+	}
+
+	startReq := &StartInvitationRequest{
+		Orgs:        []*OrgInfo{org1},
+		ChannelName: "channel1",
+		Identity:    id,
+	}
+	data, err := json.Marshal(startReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post("http://127.0.0.1:8080/channel/invitation/start", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(ret))
+
+	resp, err = http.Get("http://127.0.0.1:8080/channel/channel1/invitations?org=testorg1&peer=172.16.93.215:56051&orderer=172.16.93.215:56050")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err = ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(ret))
+}
+
+func TestInviteCode(t *testing.T) {
+	inviteReq := &InviteCodeRequest{
+		Orgs: []*OrgInfo{
+			&OrgInfo{
+				OrgName: "testorg1",
+				OrgMSP:  "testorg1",
+				MspID:   "testorg1",
+				OrdererNodes: []*ServiceNode{
+					&ServiceNode{
+						ID:               "orderer0",
+						Endpoint:         "172.16.93.215:56050",
+						ExternalEndpoint: "172.16.93.215:56050",
+						Public:           true,
+					},
+				},
+			},
+		},
+		ChannelName: "channel1",
+		ExpiresIn:   3600,
+	}
+	data, err := json.Marshal(inviteReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post("http://127.0.0.1:8080/channel/invitecode", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	code, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(string(code))
+	}
+	inviterCA, err := GetCA("../msp/testorg1", "testorg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inviterRoot, err := inviterCA.RootCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	joinReq := &OrgJoinChannelRequest{
+		Orgs: []*OrgInfo{
+			&OrgInfo{
+				OrgName: "testorg2",
+				OrgMSP:  "testorg2",
+				MspID:   "testorg2",
+				PeerNodes: []*ServiceNode{
+					&ServiceNode{
+						ID:               "peer0",
+						Endpoint:         "172.16.93.215:56251",
+						ExternalEndpoint: "172.16.93.215:56251",
+						Public:           true,
+					},
+				},
+			},
+		},
+		InviteCode:     code,
+		InviterCACerts: [][]byte{inviterRoot},
+	}
+	data, err = json.Marshal(joinReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Post("http://127.0.0.1:8080/channel/invitecode/redeem", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Log(string(ret))
+}
+
+func TestPendingUpdate(t *testing.T) {
+	id, err := ioutil.ReadFile("newOrgIdentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	orderers := []*ServiceNode{
+		&ServiceNode{
+			ID:               "orderer0",
+			Endpoint:         "172.16.93.215:56050",
+			ExternalEndpoint: "172.16.93.215:56050",
+			Public:           true,
+		},
+	}
+	org1 := &OrgInfo{
+		OrgName:      "testorg1",
+		OrgMSP:       "testorg1",
+		MspID:        "testorg1",
+		OrdererNodes: orderers,
+	}
+	org2 := &OrgInfo{
+		OrgName:      "testorg2",
+		OrgMSP:       "testorg2",
+		MspID:        "testorg2",
+		OrdererNodes: orderers,
+	}
+
+	proposeReq := &PendingUpdateRequest{
+		Orgs:        []*OrgInfo{org1},
+		ChannelName: "channel1",
+		Identity:    id,
+	}
+	data, err := json.Marshal(proposeReq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post("http://127.0.0.1:8080/channel/configupdates", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := &PendingUpdateStatus{}
+	if err := json.NewDecoder(resp.Body).Decode(status); err != nil {
+		t.Fatal(err)
+	}
+	if status.ID == "" {
+		t.Fatal("pending update is not created")
+	}
+
+	for _, org := range []*OrgInfo{org1, org2} {
+		data, err = json.Marshal(&PendingSignatureRequest{Orgs: []*OrgInfo{org}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp, err = http.Post("http://127.0.0.1:8080/channel/configupdates/"+status.ID+"/signatures", "application/json", bytes.NewBuffer(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Log(string(ret))
+	}
+
+	data, err = json.Marshal(&PendingSubmitRequest{Orgs: []*OrgInfo{org1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err = http.Post("http://127.0.0.1:8080/channel/configupdates/"+status.ID+"/submit", "application/json", bytes.NewBuffer(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ret, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		t.Fatal(string(ret))
+	}
+	t.Log(string(ret))
+}
+
+func TestUpdateOrdererConfig(t *testing.T) {
+	req := &OrdererConfigRequest{
+		Orgs: []*OrgInfo{
+			&OrgInfo{
+				OrgName: "testorg1",
+				OrgMSP:  "testorg1",
+				MspID:   "testorg1",
+				OrdererNodes: []*ServiceNode{
+					&ServiceNode{
+						ID:               "orderer0",
+						Endpoint:         "172.16.93.215:56050",
+						ExternalEndpoint: "172.16.93.215:56050",
+						Public:           true,
+					},
+				},
+			},
+		},
+		ChannelName: "channel1",
+		BatchConfig: BatchConfig{
+			BatchTimeout:    "1s",
+			MaxMessageCount: 50,
+		},
+	}
+	data, err := json.Marshal(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, url := range []string{"http://127.0.0.1:8080/channel/orderer?dryRun=true", "http://127.0.0.1:8080/channel/orderer"} {
+		resp, err := http.Post(url, "application/json", bytes.NewBuffer(data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, err := ioutil.ReadAll(resp.Body)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			t.Fatal(string(ret))
+		}
+		t.Log(string(ret))
+	}
+}
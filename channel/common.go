@@ -2,7 +2,7 @@ package channel
 
 import (
 	"encoding/json"
-	"errors"
+	"manageChain/protocols"
 	"time"
 
 	pp "github.com/hyperledger/fabric/protos/peer"
@@ -39,32 +39,36 @@ func serviceNodesToEndpointList(serviceNodes []*ServiceNode, timeout time.Durati
 }
 
 func endorseOneOfList(client *sdk.Client, chainID string, chaincode string, args [][]byte, transient map[string][]byte, peerEndpoints []*sdk.Endpoint) (txID string, prop *pp.Proposal, resps []*pp.ProposalResponse, endorser *sdk.Endpoint, err error) {
+	if len(peerEndpoints) == 0 {
+		return "", nil, nil, nil, protocols.Errorf(protocols.CodeBadRequest, "no peers can be found")
+	}
+	failed := protocols.Errorf(protocols.CodeEndorsementFailed, "failed proposing through all peers")
 	for _, peer := range peerEndpoints {
 		txID, prop, resps, err = client.Endorse(chainID, chaincode, args, transient, []*sdk.Endpoint{peer})
 		if err == nil {
 			endorser = peer
-			break
+			return
 		}
 		logger.Error("Error endorsing", err)
+		failed.AddDetail(peer.Address, err)
 	}
-	if err != nil {
-		return "", nil, nil, nil, errors.New("failed proposing through all peers")
-	}
-	return
+	return "", nil, nil, nil, failed
 }
 
 func broadcastOneOfList(client *sdk.Client, prop *pp.Proposal, resps []*pp.ProposalResponse, ordererEndpoints []*sdk.Endpoint) (err error) {
+	if len(ordererEndpoints) == 0 {
+		return protocols.Errorf(protocols.CodeBadRequest, "no orderers can be found")
+	}
+	failed := protocols.Errorf(protocols.CodeBroadcastFailed, "failed broadcasting through all orderers")
 	for _, orderer := range ordererEndpoints {
 		err = client.Broadcast(prop, resps, orderer)
 		if err == nil {
-			break
+			return nil
 		}
 		logger.Error("Error broadcasting", err)
+		failed.AddDetail(orderer.Address, err)
 	}
-	if err != nil {
-		return errors.New("failed broadcasting through all orderers")
-	}
-	return
+	return failed
 }
 
 func invoke(client *sdk.Client, chainID string, chaincode string, args [][]byte, peers []*sdk.Endpoint, orderers []*sdk.Endpoint) error {
@@ -80,14 +84,19 @@ func invoke(client *sdk.Client, chainID string, chaincode string, args [][]byte,
 		return err
 	}
 
-	valid, err := client.WaitTx(chainID, txID, endorder, waitTxTimeout)
+	status, err := client.WaitTxStatus(chainID, txID, endorder, waitTxTimeout)
+	if err == sdk.ErrClosed {
+		return protocols.Errorf(protocols.CodeTimeout, "timeout waiting transaction %s on %s", txID, endorder.Address)
+	}
 	if err != nil {
 		logger.Error("Error waiting transaction", err)
 		return err
 	}
 
-	if !valid {
-		return errors.New("invoke is not valid, please try again")
+	if status.ValidationCode != pp.TxValidationCode_VALID {
+		invalid := protocols.Errorf(protocols.CodeTxInvalid, "invoke %s is not valid, please try again", txID)
+		invalid.ValidationCode = status.ValidationCode.String()
+		return invalid
 	}
 
 	return nil
@@ -0,0 +1,262 @@
+package channel
+
+import (
+	"errors"
+	"manageChain/protocols"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+// channelConfig fetches the current config of channelName through the
+// orderers of the first org
+func (c *Channel) channelConfig(channelName string) (*cb.Config, error) {
+	block, err := c.configBlock(channelName)
+	if err != nil {
+		return nil, err
+	}
+	return configFromBlock(block)
+}
+
+func (c *Channel) configBlock(channelName string) (*cb.Block, error) {
+	casters := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, c.orgs[0].OrgCA.TLSCACert())
+	for _, caster := range casters {
+		block, err := c.orgs[0].Client.GetConfigBlockByChannel(channelName, caster)
+		if err != nil {
+			logger.Error("Error getting config block from chain %s: %s", channelName, err)
+			continue
+		}
+		return block, nil
+	}
+	return nil, errors.New("failed getting config block after try all orderers")
+}
+
+// errConfigUnchanged tells updateConfigs a channel is already as requested
+var errConfigUnchanged = errors.New("config is already as requested")
+
+// updateConfigs runs updateConfig with edit on channelNames, the system
+// channel first as the application channels are created from it. The
+// channels edit returns errConfigUnchanged for are skipped. It stops at the
+// first channel failing and returns the previews of the channels updated
+// before
+func (c *Channel) updateConfigs(channelNames []string, dryRun bool, edit func(channelName string, config *cb.Config) error) ([]*ConfigUpdatePreview, error) {
+	var ordered []string
+	for _, name := range channelNames {
+		if name == c.network.systemChannel() {
+			ordered = append([]string{name}, ordered...)
+		} else {
+			ordered = append(ordered, name)
+		}
+	}
+
+	var previews []*ConfigUpdatePreview
+	for _, channelName := range ordered {
+		preview, err := c.updateConfig(channelName, dryRun, func(config *cb.Config) error {
+			return edit(channelName, config)
+		})
+		if err == errConfigUnchanged {
+			logger.Info("Skipping channel %s, %s", channelName, err)
+			continue
+		}
+		if err != nil {
+			logger.Error("Error updating config of %s after %d channels: %s", channelName, len(previews), err)
+			return previews, err
+		}
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
+
+// updateConfig applies edit to a copy of the latest config of channelName.
+// The config update between them is signed by all orgs of c and broadcast
+// by the first one, unless dryRun which only previews it
+func (c *Channel) updateConfig(channelName string, dryRun bool, edit func(config *cb.Config) error) (*ConfigUpdatePreview, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	c.step("compute config update")
+	original, err := c.channelConfig(channelName)
+	if err != nil {
+		logger.Error("Error getting channel config", err)
+		return nil, err
+	}
+	updated := proto.Clone(original).(*cb.Config)
+	if err := edit(updated); err != nil {
+		return nil, err
+	}
+	preview, err := previewConfigUpdate(channelName, original, updated)
+	if err != nil {
+		logger.Error("Error computing config update", err)
+		return nil, err
+	}
+	if dryRun {
+		return preview, nil
+	}
+	if len(preview.Changes) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "config of %s is already as requested", channelName)
+	}
+
+	c.step("sign config update")
+	sigs, err := signConfigUpdate(c.orgs, preview.ConfigUpdate)
+	if err != nil {
+		logger.Error("Error signing config update", err)
+		return nil, err
+	}
+	c.step("broadcast config update")
+	if err := c.broadcastConfigUpdate(channelName, preview.ConfigUpdate, sigs); err != nil {
+		return nil, err
+	}
+	return preview, nil
+}
+
+// signConfigUpdate returns the signatures of the admins of orgs over update
+func signConfigUpdate(orgs []*OrgInfo, update []byte) ([]*cb.ConfigSignature, error) {
+	sigs := []*cb.ConfigSignature{}
+	for _, org := range orgs {
+		sigHeader, signature, err := org.Client.SignChannelConfigUpdate(update)
+		if err != nil {
+			return nil, err
+		}
+		sigs = append(sigs, &cb.ConfigSignature{
+			SignatureHeader: sigHeader,
+			Signature:       signature,
+		})
+	}
+	return sigs, nil
+}
+
+// broadcastConfigUpdate sends update with sigs to the orderers of the
+// first org until one accepts it
+func (c *Channel) broadcastConfigUpdate(channelName string, update []byte, sigs []*cb.ConfigSignature) error {
+	casters := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, c.orgs[0].OrgCA.TLSCACert())
+	failed := protocols.Errorf(protocols.CodeBroadcastFailed, "failed updating channel %s after try all orderers", channelName)
+	for _, caster := range casters {
+		if err := c.orgs[0].Client.UpdateChannelByConfigUpdate(channelName, update, sigs, caster); err != nil {
+			logger.Error("Error update channel", err)
+			failed.AddDetail(caster.Address, err)
+			continue
+		}
+		logger.Info("Successfully update channel %s", channelName)
+		return nil
+	}
+	return failed
+}
+
+func configFromBlock(block *cb.Block) (*cb.Config, error) {
+	env, err := utils.ExtractEnvelope(block, 0)
+	if err != nil {
+		return nil, err
+	}
+	payload, err := utils.UnmarshalPayload(env.Payload)
+	if err != nil {
+		return nil, err
+	}
+	configEnv := &cb.ConfigEnvelope{}
+	if err := proto.Unmarshal(payload.Data, configEnv); err != nil {
+		return nil, err
+	}
+	if configEnv.Config == nil || configEnv.Config.ChannelGroup == nil {
+		return nil, errors.New("config is missing in config block")
+	}
+	return configEnv.Config, nil
+}
+
+// blockChannelID returns the channel of the first transaction of block
+func blockChannelID(block *cb.Block) (string, error) {
+	env, err := utils.ExtractEnvelope(block, 0)
+	if err != nil {
+		return "", err
+	}
+	return utils.ChannelID(env)
+}
+
+// ordererInfo returns the orderer addresses of config and the tls root
+// certs of its orderer orgs
+func ordererInfo(config *cb.Config) (addresses []string, tlsCACerts [][]byte, err error) {
+	if value, ok := config.ChannelGroup.Values[channelconfig.OrdererAddressesKey]; ok {
+		oa := &cb.OrdererAddresses{}
+		if err := proto.Unmarshal(value.Value, oa); err != nil {
+			return nil, nil, err
+		}
+		addresses = oa.Addresses
+	}
+	msps, err := groupMSPs(config, channelconfig.OrdererGroupKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, mspConfig := range msps {
+		fmsp, err := fabricMSPConfig(mspConfig)
+		if err != nil {
+			return nil, nil, err
+		}
+		tlsCACerts = append(tlsCACerts, fmsp.TlsRootCerts...)
+	}
+	return addresses, tlsCACerts, nil
+}
+
+// groupMSPs returns the msp configs of the orgs of the groupKey group of
+// config, by msp id
+func groupMSPs(config *cb.Config, groupKey string) (map[string]*mspprotos.MSPConfig, error) {
+	msps := make(map[string]*mspprotos.MSPConfig)
+	group, ok := config.ChannelGroup.Groups[groupKey]
+	if !ok {
+		return msps, nil
+	}
+	if err := addOrgMSPs(msps, group); err != nil {
+		return nil, err
+	}
+	return msps, nil
+}
+
+// channelMSPs returns the msp configs of every org of config, application
+// and orderer orgs, or consortium orgs in the system channel
+func channelMSPs(config *cb.Config) (map[string]*mspprotos.MSPConfig, error) {
+	msps := make(map[string]*mspprotos.MSPConfig)
+	groups := config.ChannelGroup.Groups
+	for _, key := range []string{channelconfig.ApplicationGroupKey, channelconfig.OrdererGroupKey} {
+		if group, ok := groups[key]; ok {
+			if err := addOrgMSPs(msps, group); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if consortiums, ok := groups[channelconfig.ConsortiumsGroupKey]; ok {
+		for _, consortium := range consortiums.Groups {
+			if err := addOrgMSPs(msps, consortium); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return msps, nil
+}
+
+// addOrgMSPs adds the msp configs of the orgs of group to msps
+func addOrgMSPs(msps map[string]*mspprotos.MSPConfig, group *cb.ConfigGroup) error {
+	for _, org := range group.Groups {
+		value, ok := org.Values[channelconfig.MSPKey]
+		if !ok {
+			continue
+		}
+		mspConfig := &mspprotos.MSPConfig{}
+		if err := proto.Unmarshal(value.Value, mspConfig); err != nil {
+			return err
+		}
+		fmsp, err := fabricMSPConfig(mspConfig)
+		if err != nil {
+			return err
+		}
+		msps[fmsp.Name] = mspConfig
+	}
+	return nil
+}
+
+func fabricMSPConfig(mspConfig *mspprotos.MSPConfig) (*mspprotos.FabricMSPConfig, error) {
+	fmsp := &mspprotos.FabricMSPConfig{}
+	if err := proto.Unmarshal(mspConfig.Config, fmsp); err != nil {
+		return nil, err
+	}
+	return fmsp, nil
+}
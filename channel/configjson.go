@@ -0,0 +1,222 @@
+package channel
+
+import (
+	"encoding/json"
+	"fmt"
+	"manageChain/protocols"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+)
+
+// ConfigJSON fetches the latest config of channelName through the
+// orderers of the first org, with the known values and policies decoded
+func (c *Channel) ConfigJSON(channelName string) (*ConfigJSON, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	config, err := c.channelConfig(channelName)
+	if err != nil {
+		logger.Error("Error getting channel config", err)
+		return nil, err
+	}
+	return encodeConfig(config)
+}
+
+// PreviewConfigEdit computes the config update from the latest config of
+// channelName to edited, nothing is signed nor broadcast
+func (c *Channel) PreviewConfigEdit(channelName string, edited *ConfigJSON) (*ConfigUpdatePreview, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	if edited == nil || edited.ChannelGroup == nil {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "edited config should not be empty")
+	}
+	c.step("compute config update")
+	original, err := c.channelConfig(channelName)
+	if err != nil {
+		logger.Error("Error getting channel config", err)
+		return nil, err
+	}
+	if edited.Sequence != original.Sequence {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "config is edited from sequence %d but channel %s is at %d", edited.Sequence, channelName, original.Sequence)
+	}
+	updated, err := decodeConfig(edited)
+	if err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	return previewConfigUpdate(channelName, original, updated)
+}
+
+// ProposeConfigEdit stores the config update from the latest config of
+// channelName to edited as a pending update for the members to sign
+func (c *Channel) ProposeConfigEdit(channelName string, edited *ConfigJSON) (*PendingUpdateStatus, error) {
+	preview, err := c.PreviewConfigEdit(channelName, edited)
+	if err != nil {
+		return nil, err
+	}
+	if len(preview.Changes) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "edited config of %s has no differences", channelName)
+	}
+	return c.ProposeConfigUpdate(&PendingUpdateRequest{
+		ChannelName:  channelName,
+		ConfigUpdate: preview.ConfigUpdate,
+	})
+}
+
+func encodeConfig(config *cb.Config) (*ConfigJSON, error) {
+	group, err := encodeConfigGroup(config.ChannelGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &ConfigJSON{
+		Sequence:     config.Sequence,
+		ChannelGroup: group,
+	}, nil
+}
+
+func decodeConfig(config *ConfigJSON) (*cb.Config, error) {
+	group, err := decodeConfigGroup(config.ChannelGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &cb.Config{
+		Sequence:     config.Sequence,
+		ChannelGroup: group,
+	}, nil
+}
+
+func encodeConfigGroup(group *cb.ConfigGroup) (*ConfigGroupJSON, error) {
+	ret := &ConfigGroupJSON{
+		Version:   group.Version,
+		ModPolicy: group.ModPolicy,
+		Groups:    make(map[string]*ConfigGroupJSON),
+		Values:    make(map[string]*ConfigValueJSON),
+		Policies:  make(map[string]*ConfigPolicyJSON),
+	}
+	for key, sub := range group.Groups {
+		g, err := encodeConfigGroup(sub)
+		if err != nil {
+			return nil, err
+		}
+		ret.Groups[key] = g
+	}
+	for key, value := range group.Values {
+		v, err := encodeConfigValue(key, value.Value)
+		if err != nil {
+			return nil, err
+		}
+		ret.Values[key] = &ConfigValueJSON{
+			Version:   value.Version,
+			ModPolicy: value.ModPolicy,
+			Value:     v,
+		}
+	}
+	for key, policy := range group.Policies {
+		p := &ConfigPolicyJSON{
+			Version:   policy.Version,
+			ModPolicy: policy.ModPolicy,
+		}
+		if policy.Policy != nil {
+			v, err := marshalProtoJSON(policy.Policy)
+			if err != nil {
+				return nil, err
+			}
+			p.Policy = v
+		}
+		ret.Policies[key] = p
+	}
+	return ret, nil
+}
+
+func decodeConfigGroup(group *ConfigGroupJSON) (*cb.ConfigGroup, error) {
+	ret := cb.NewConfigGroup()
+	ret.Version = group.Version
+	ret.ModPolicy = group.ModPolicy
+	for key, sub := range group.Groups {
+		g, err := decodeConfigGroup(sub)
+		if err != nil {
+			return nil, err
+		}
+		ret.Groups[key] = g
+	}
+	for key, value := range group.Values {
+		v, err := decodeJSONConfigValue(key, value.Value)
+		if err != nil {
+			return nil, err
+		}
+		ret.Values[key] = &cb.ConfigValue{
+			Version:   value.Version,
+			ModPolicy: value.ModPolicy,
+			Value:     v,
+		}
+	}
+	for key, policy := range group.Policies {
+		p := &cb.ConfigPolicy{
+			Version:   policy.Version,
+			ModPolicy: policy.ModPolicy,
+		}
+		if len(policy.Policy) != 0 && string(policy.Policy) != "null" {
+			p.Policy = &cb.Policy{}
+			if err := unmarshalProtoJSON(policy.Policy, p.Policy); err != nil {
+				return nil, fmt.Errorf("error decoding policy %s: %s", key, err)
+			}
+		}
+		ret.Policies[key] = p
+	}
+	return ret, nil
+}
+
+// configValueMessage returns the message of the value of key, nil for the
+// unknown keys
+func configValueMessage(key string) proto.Message {
+	switch key {
+	case channelconfig.MSPKey:
+		return &mspprotos.MSPConfig{}
+	case channelconfig.ChannelCreationPolicyKey:
+		return &cb.Policy{}
+	}
+	if newMsg, ok := configValueMessages[key]; ok {
+		return newMsg()
+	}
+	return nil
+}
+
+// encodeConfigValue returns the value of key as json, the messages of
+// configValueMessage are decoded, any other value is kept as base64 of its
+// bytes
+func encodeConfigValue(key string, value []byte) (json.RawMessage, error) {
+	msg := configValueMessage(key)
+	if msg == nil {
+		return json.Marshal(value)
+	}
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return nil, err
+	}
+	return marshalProtoJSON(msg)
+}
+
+func decodeJSONConfigValue(key string, value json.RawMessage) ([]byte, error) {
+	msg := configValueMessage(key)
+	if msg == nil {
+		var raw []byte
+		err := json.Unmarshal(value, &raw)
+		return raw, err
+	}
+	if err := unmarshalProtoJSON(value, msg); err != nil {
+		return nil, fmt.Errorf("error decoding value %s: %s", key, err)
+	}
+	return proto.Marshal(msg)
+}
+
+func policyMessage(typ int32) proto.Message {
+	switch typ {
+	case int32(cb.Policy_SIGNATURE):
+		return &cb.SignaturePolicyEnvelope{}
+	case int32(cb.Policy_IMPLICIT_META):
+		return &cb.ImplicitMetaPolicy{}
+	}
+	return nil
+}
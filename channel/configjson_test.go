@@ -0,0 +1,103 @@
+package channel
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestConfigJSON(t *testing.T) {
+	msp := &mspprotos.MSPConfig{
+		Config: utils.MarshalOrPanic(&mspprotos.FabricMSPConfig{
+			Name:      "org1",
+			RootCerts: [][]byte{[]byte("-----BEGIN CERTIFICATE-----")},
+		}),
+	}
+	config := &cb.Config{
+		Sequence: 3,
+		ChannelGroup: &cb.ConfigGroup{
+			Version:   1,
+			ModPolicy: channelconfig.AdminsPolicyKey,
+			Values: map[string]*cb.ConfigValue{
+				channelconfig.OrdererAddressesKey: &cb.ConfigValue{
+					ModPolicy: channelconfig.AdminsPolicyKey,
+					Value:     utils.MarshalOrPanic(&cb.OrdererAddresses{Addresses: []string{"orderer0:7050"}}),
+				},
+				"Unknown": &cb.ConfigValue{Value: []byte("raw")},
+			},
+			Policies: map[string]*cb.ConfigPolicy{
+				channelconfig.AdminsPolicyKey: &cb.ConfigPolicy{
+					ModPolicy: channelconfig.AdminsPolicyKey,
+					Policy: &cb.Policy{
+						Type: int32(cb.Policy_IMPLICIT_META),
+						Value: utils.MarshalOrPanic(&cb.ImplicitMetaPolicy{
+							Rule:      cb.ImplicitMetaPolicy_MAJORITY,
+							SubPolicy: channelconfig.AdminsPolicyKey,
+						}),
+					},
+				},
+			},
+			Groups: map[string]*cb.ConfigGroup{
+				channelconfig.OrdererGroupKey: &cb.ConfigGroup{
+					Values: map[string]*cb.ConfigValue{
+						channelconfig.BatchSizeKey: &cb.ConfigValue{
+							Value: utils.MarshalOrPanic(&ab.BatchSize{MaxMessageCount: 10}),
+						},
+					},
+					Groups: map[string]*cb.ConfigGroup{
+						"org1": &cb.ConfigGroup{
+							Values: map[string]*cb.ConfigValue{
+								channelconfig.MSPKey: &cb.ConfigValue{Value: utils.MarshalOrPanic(msp)},
+							},
+							Policies: map[string]*cb.ConfigPolicy{
+								channelconfig.ReadersPolicyKey: &cb.ConfigPolicy{
+									ModPolicy: channelconfig.AdminsPolicyKey,
+									Policy: &cb.Policy{
+										Type:  int32(cb.Policy_SIGNATURE),
+										Value: utils.MarshalOrPanic(cauthdsl.SignedByAnyMember([]string{"org1"})),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	encoded, err := encodeConfig(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	edited := &ConfigJSON{}
+	if err := json.Unmarshal(data, edited); err != nil {
+		t.Fatal(err)
+	}
+	if string(edited.ChannelGroup.Values[channelconfig.OrdererAddressesKey].Value) != `{"addresses":["orderer0:7050"]}` {
+		t.Fatalf("unexpected orderer addresses %s", edited.ChannelGroup.Values[channelconfig.OrdererAddressesKey].Value)
+	}
+	org := edited.ChannelGroup.Groups[channelconfig.OrdererGroupKey].Groups["org1"]
+	expected := `{"type":1,"value":{"identities":[{"principal":{"msp_identifier":"org1","role":"MEMBER"},"principal_classification":"ROLE"}],"rule":{"n_out_of":{"n":1,"rules":[{"signed_by":0}]}},"version":0}}`
+	if string(org.Policies[channelconfig.ReadersPolicyKey].Policy) != expected {
+		t.Fatalf("unexpected signature policy %s", org.Policies[channelconfig.ReadersPolicyKey].Policy)
+	}
+
+	decoded, err := decodeConfig(edited)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(config, decoded) {
+		t.Fatalf("config changed through json:\n%v\n%v", config, decoded)
+	}
+}
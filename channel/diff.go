@@ -0,0 +1,338 @@
+package channel
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/tools/configtxlator/update"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	pb "github.com/hyperledger/fabric/protos/peer"
+)
+
+const rootGroupPath = "/" + channelconfig.RootGroupKey
+
+// configValueMessages are the messages of the known config values by key
+var configValueMessages = map[string]func() proto.Message{
+	channelconfig.ConsortiumKey:                func() proto.Message { return &cb.Consortium{} },
+	channelconfig.HashingAlgorithmKey:          func() proto.Message { return &cb.HashingAlgorithm{} },
+	channelconfig.BlockDataHashingStructureKey: func() proto.Message { return &cb.BlockDataHashingStructure{} },
+	channelconfig.OrdererAddressesKey:          func() proto.Message { return &cb.OrdererAddresses{} },
+	channelconfig.CapabilitiesKey:              func() proto.Message { return &cb.Capabilities{} },
+	channelconfig.ConsensusTypeKey:             func() proto.Message { return &ab.ConsensusType{} },
+	channelconfig.BatchSizeKey:                 func() proto.Message { return &ab.BatchSize{} },
+	channelconfig.BatchTimeoutKey:              func() proto.Message { return &ab.BatchTimeout{} },
+	channelconfig.ChannelRestrictionsKey:       func() proto.Message { return &ab.ChannelRestrictions{} },
+	channelconfig.KafkaBrokersKey:              func() proto.Message { return &ab.KafkaBrokers{} },
+	channelconfig.AnchorPeersKey:               func() proto.Message { return &pb.AnchorPeers{} },
+	channelconfig.ACLsKey:                      func() proto.Message { return &pb.ACLs{} },
+}
+
+// previewConfigUpdate describes how updated differs from original and what
+// the config update of chainID between them needs to be accepted
+func previewConfigUpdate(chainID string, original, updated *cb.Config) (*ConfigUpdatePreview, error) {
+	preview := &ConfigUpdatePreview{
+		ChannelName: chainID,
+		Changes:     diffGroup(rootGroupPath, original.ChannelGroup, updated.ChannelGroup),
+	}
+	if len(preview.Changes) == 0 {
+		return preview, nil
+	}
+
+	configUpdate, err := update.Compute(original, updated)
+	if err != nil {
+		return nil, err
+	}
+	configUpdate.ChannelId = chainID
+	if preview.ConfigUpdate, err = proto.Marshal(configUpdate); err != nil {
+		return nil, err
+	}
+	preview.ReadSet = configElements(rootGroupPath, configUpdate.ReadSet)
+	preview.WriteSet = configElements(rootGroupPath, configUpdate.WriteSet)
+	preview.Policies = modPolicies(original, configUpdate)
+	return preview, nil
+}
+
+// diffGroup lists the changes from old to new of the group at path
+func diffGroup(path string, old, new *cb.ConfigGroup) (changes []*ConfigChange) {
+	if old.ModPolicy != new.ModPolicy {
+		changes = append(changes, &ConfigChange{
+			Path:   path,
+			Kind:   ConfigKindModPolicy,
+			Action: ConfigModified,
+			Old:    old.ModPolicy,
+			New:    new.ModPolicy,
+		})
+	}
+
+	for _, key := range unionKeys(old.Values, new.Values) {
+		o, n := old.Values[key], new.Values[key]
+		if o != nil && n != nil && bytes.Equal(o.Value, n.Value) {
+			continue
+		}
+		change := &ConfigChange{Path: path + "/" + key, Kind: ConfigKindValue}
+		if o != nil {
+			change.Old = decodeConfigValue(key, o.Value)
+		}
+		if n != nil {
+			change.New = decodeConfigValue(key, n.Value)
+		}
+		changes = append(changes, change.withAction(o != nil, n != nil))
+	}
+
+	for _, key := range unionKeys(old.Policies, new.Policies) {
+		o, n := old.Policies[key], new.Policies[key]
+		if o != nil && n != nil && proto.Equal(o, n) {
+			continue
+		}
+		change := &ConfigChange{Path: path + "/" + key, Kind: ConfigKindPolicy}
+		if o != nil {
+			change.Old = describePolicy(o.Policy)
+		}
+		if n != nil {
+			change.New = describePolicy(n.Policy)
+		}
+		changes = append(changes, change.withAction(o != nil, n != nil))
+	}
+
+	for _, key := range unionKeys(old.Groups, new.Groups) {
+		o, n := old.Groups[key], new.Groups[key]
+		if o != nil && n != nil {
+			changes = append(changes, diffGroup(path+"/"+key, o, n)...)
+			continue
+		}
+		change := &ConfigChange{Path: path + "/" + key, Kind: ConfigKindGroup}
+		if o != nil {
+			change.Old = summarizeGroup(o)
+		}
+		if n != nil {
+			change.New = summarizeGroup(n)
+		}
+		changes = append(changes, change.withAction(o != nil, n != nil))
+	}
+	return changes
+}
+
+func (c *ConfigChange) withAction(inOld, inNew bool) *ConfigChange {
+	switch {
+	case !inOld:
+		c.Action = ConfigAdded
+	case !inNew:
+		c.Action = ConfigRemoved
+	default:
+		c.Action = ConfigModified
+	}
+	return c
+}
+
+// summarizeGroup decodes the values of an added or removed group, its msp
+// is given by msp id and its subgroups by name
+func summarizeGroup(group *cb.ConfigGroup) map[string]interface{} {
+	summary := make(map[string]interface{})
+	for key, value := range group.Values {
+		summary[key] = decodeConfigValue(key, value.Value)
+	}
+	for key, policy := range group.Policies {
+		summary[key] = describePolicy(policy.Policy)
+	}
+	if len(group.Groups) != 0 {
+		var names []string
+		for name := range group.Groups {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		summary["Groups"] = names
+	}
+	return summary
+}
+
+// decodeConfigValue returns the known value of key decoded, the msp by its
+// id, or the raw bytes
+func decodeConfigValue(key string, value []byte) interface{} {
+	if key == channelconfig.MSPKey {
+		mspConfig := &mspprotos.MSPConfig{}
+		if err := proto.Unmarshal(value, mspConfig); err != nil {
+			return value
+		}
+		fmsp, err := fabricMSPConfig(mspConfig)
+		if err != nil {
+			return value
+		}
+		return fmsp.Name
+	}
+	newMsg, ok := configValueMessages[key]
+	if !ok {
+		return value
+	}
+	msg := newMsg()
+	if err := proto.Unmarshal(value, msg); err != nil {
+		return value
+	}
+	return msg
+}
+
+// describePolicy returns "RULE SubPolicy" for an implicit meta policy
+func describePolicy(policy *cb.Policy) string {
+	if policy == nil {
+		return ""
+	}
+	switch policy.Type {
+	case int32(cb.Policy_IMPLICIT_META):
+		meta := &cb.ImplicitMetaPolicy{}
+		if err := proto.Unmarshal(policy.Value, meta); err != nil {
+			return "invalid implicit meta policy"
+		}
+		return meta.Rule.String() + " " + meta.SubPolicy
+	case int32(cb.Policy_SIGNATURE):
+		return "signature policy"
+	default:
+		return cb.Policy_PolicyType_name[policy.Type]
+	}
+}
+
+// configElements flattens group into its elements with their versions
+func configElements(path string, group *cb.ConfigGroup) []*ConfigElement {
+	if group == nil {
+		return nil
+	}
+	elements := []*ConfigElement{&ConfigElement{
+		Path:      path,
+		Kind:      ConfigKindGroup,
+		Version:   group.Version,
+		ModPolicy: group.ModPolicy,
+	}}
+	for _, key := range sortedKeys(group.Values) {
+		elements = append(elements, &ConfigElement{
+			Path:      path + "/" + key,
+			Kind:      ConfigKindValue,
+			Version:   group.Values[key].Version,
+			ModPolicy: group.Values[key].ModPolicy,
+		})
+	}
+	for _, key := range sortedKeys(group.Policies) {
+		elements = append(elements, &ConfigElement{
+			Path:      path + "/" + key,
+			Kind:      ConfigKindPolicy,
+			Version:   group.Policies[key].Version,
+			ModPolicy: group.Policies[key].ModPolicy,
+		})
+	}
+	for _, key := range sortedKeys(group.Groups) {
+		elements = append(elements, configElements(path+"/"+key, group.Groups[key])...)
+	}
+	return elements
+}
+
+// modPolicies returns the policies the orderer evaluates for configUpdate,
+// the mod policy of every element of original whose version is bumped
+func modPolicies(original *cb.Config, configUpdate *cb.ConfigUpdate) []*PolicyRequirement {
+	read := make(map[string]uint64)
+	for _, element := range configElements(rootGroupPath, configUpdate.ReadSet) {
+		read[element.Path] = element.Version
+	}
+	current := make(map[string]*ConfigElement)
+	for _, element := range configElements(rootGroupPath, original.ChannelGroup) {
+		current[element.Path] = element
+	}
+
+	requirements := make(map[string]*PolicyRequirement)
+	var paths []string
+	for _, element := range configElements(rootGroupPath, configUpdate.WriteSet) {
+		version, ok := read[element.Path]
+		if ok && version == element.Version {
+			continue
+		}
+		old, ok := current[element.Path]
+		if !ok {
+			// a new element is governed by its parent group, whose
+			// version is bumped as well
+			continue
+		}
+		policyPath := resolvePolicyPath(old, element.Path)
+		requirement, ok := requirements[policyPath]
+		if !ok {
+			requirement = &PolicyRequirement{
+				Path: policyPath,
+				Rule: describePolicy(lookupPolicy(original, policyPath)),
+			}
+			requirements[policyPath] = requirement
+			paths = append(paths, policyPath)
+		}
+		requirement.Elements = append(requirement.Elements, element.Path)
+	}
+
+	sort.Strings(paths)
+	var ret []*PolicyRequirement
+	for _, path := range paths {
+		ret = append(ret, requirements[path])
+	}
+	return ret
+}
+
+// resolvePolicyPath returns the absolute path of the mod policy of element,
+// a relative one is relative to the group of the element
+func resolvePolicyPath(element *ConfigElement, path string) string {
+	if strings.HasPrefix(element.ModPolicy, "/") {
+		return element.ModPolicy
+	}
+	group := path
+	if element.Kind != ConfigKindGroup {
+		group = path[:strings.LastIndex(path, "/")]
+	}
+	return group + "/" + element.ModPolicy
+}
+
+func lookupPolicy(config *cb.Config, path string) *cb.Policy {
+	names := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if len(names) < 2 || names[0] != channelconfig.RootGroupKey {
+		return nil
+	}
+	group := config.ChannelGroup
+	for _, name := range names[1 : len(names)-1] {
+		if group = group.Groups[name]; group == nil {
+			return nil
+		}
+	}
+	if policy, ok := group.Policies[names[len(names)-1]]; ok {
+		return policy.Policy
+	}
+	return nil
+}
+
+func unionKeys(maps ...interface{}) []string {
+	m := make(map[string]bool)
+	for _, keys := range maps {
+		for _, key := range sortedKeys(keys) {
+			m[key] = true
+		}
+	}
+	var keys []string
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func sortedKeys(m interface{}) (keys []string) {
+	switch m := m.(type) {
+	case map[string]*cb.ConfigValue:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*cb.ConfigPolicy:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	case map[string]*cb.ConfigGroup:
+		for key := range m {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,85 @@
+package channel
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestPreviewConfigUpdate(t *testing.T) {
+	admins := &cb.ConfigPolicy{
+		Policy: &cb.Policy{
+			Type: int32(cb.Policy_IMPLICIT_META),
+			Value: utils.MarshalOrPanic(&cb.ImplicitMetaPolicy{
+				Rule:      cb.ImplicitMetaPolicy_MAJORITY,
+				SubPolicy: channelconfig.AdminsPolicyKey,
+			}),
+		},
+	}
+	addresses := func(addrs ...string) *cb.ConfigValue {
+		return &cb.ConfigValue{
+			ModPolicy: channelconfig.AdminsPolicyKey,
+			Value:     utils.MarshalOrPanic(&cb.OrdererAddresses{Addresses: addrs}),
+		}
+	}
+	original := &cb.Config{
+		ChannelGroup: &cb.ConfigGroup{
+			ModPolicy: channelconfig.AdminsPolicyKey,
+			Values: map[string]*cb.ConfigValue{
+				channelconfig.OrdererAddressesKey: addresses("orderer0:7050"),
+			},
+			Policies: map[string]*cb.ConfigPolicy{
+				channelconfig.AdminsPolicyKey: admins,
+			},
+			Groups: map[string]*cb.ConfigGroup{
+				channelconfig.ApplicationGroupKey: &cb.ConfigGroup{
+					ModPolicy: channelconfig.AdminsPolicyKey,
+					Policies: map[string]*cb.ConfigPolicy{
+						channelconfig.AdminsPolicyKey: admins,
+					},
+					Groups: map[string]*cb.ConfigGroup{
+						"org1": &cb.ConfigGroup{ModPolicy: channelconfig.AdminsPolicyKey},
+					},
+				},
+			},
+		},
+	}
+	updated := proto.Clone(original).(*cb.Config)
+	updated.ChannelGroup.Values[channelconfig.OrdererAddressesKey] = addresses("orderer0:7050", "orderer1:7050")
+	updated.ChannelGroup.Groups[channelconfig.ApplicationGroupKey].Groups["org2"] = &cb.ConfigGroup{ModPolicy: channelconfig.AdminsPolicyKey}
+
+	preview, err := previewConfigUpdate("channel1", original, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	changes := map[string]string{}
+	for _, change := range preview.Changes {
+		changes[change.Path] = change.Action
+	}
+	if len(changes) != 2 ||
+		changes["/Channel/OrdererAddresses"] != ConfigModified ||
+		changes["/Channel/Application/org2"] != ConfigAdded {
+		t.Fatalf("unexpected changes %v", changes)
+	}
+
+	policies := map[string]string{}
+	for _, policy := range preview.Policies {
+		policies[policy.Path] = policy.Rule
+	}
+	if len(policies) != 2 ||
+		policies["/Channel/Admins"] != "MAJORITY Admins" ||
+		policies["/Channel/Application/Admins"] != "MAJORITY Admins" {
+		t.Fatalf("unexpected policies %v", policies)
+	}
+
+	preview, err = previewConfigUpdate("channel1", original, original)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(preview.Changes) != 0 || preview.ConfigUpdate != nil {
+		t.Fatalf("unexpected preview of unchanged config %+v", preview)
+	}
+}
@@ -0,0 +1,49 @@
+package channel
+
+import (
+	"errors"
+	"manageChain/protocols"
+
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// Discovery asks the discovery service of the channel through the first peer
+// which answers, the endorsement plan is only queried when chaincode is given
+func (c *Channel) Discovery(channelName string, chaincode string) (*DiscoveryResponse, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	peers := serviceNodesToEndpointList(c.orgs[0].PeerNodes, EndorseTimeout, c.orgs[0].OrgCA.TLSCACert())
+	if len(peers) == 0 {
+		return nil, errors.New("no bootstrap peers can be found")
+	}
+
+	var err error
+	for _, peer := range peers {
+		var resp *DiscoveryResponse
+		resp, err = discovery(c.orgs[0].Client, channelName, chaincode, peer)
+		if err == nil {
+			return resp, nil
+		}
+		logger.Error("Error discovering channel through %s: %s", peer.Address, err)
+	}
+	return nil, err
+}
+
+func discovery(client *sdk.Client, channelName string, chaincode string, peer *sdk.Endpoint) (*DiscoveryResponse, error) {
+	msps, err := client.DiscoveryChannel(channelName, peer)
+	if err != nil {
+		return nil, err
+	}
+	resp := &DiscoveryResponse{
+		Peer: peer.Address,
+		MSPs: msps,
+	}
+	if chaincode != "" {
+		resp.EndorsementPlan, err = client.DiscoveryEndorsementPlan(channelName, chaincode, peer)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
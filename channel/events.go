@@ -0,0 +1,159 @@
+package channel
+
+import (
+	"errors"
+	"manageChain/protocols"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+	pp "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// EventStream delivers the blocks of a channel to one subscriber, every
+// subscriber owns its deliver stream so a slow one only holds back itself
+type EventStream struct {
+	iter   *sdk.BlockIterator
+	eventC chan *BlockEvent
+	stopC  chan struct{}
+	err    error
+}
+
+// Subscribe starts delivering the blocks of the channel from start on, or
+// from the newest block when start is negative. Both full and filtered
+// blocks are read from the peers, so the validation codes are the committed
+// ones.
+func (c *Channel) Subscribe(channelName string, start int64, full bool) (*EventStream, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	client := c.orgs[0].Client
+	tlsCA := c.orgs[0].OrgCA.TLSCACert()
+
+	var iter *sdk.BlockIterator
+	var err error
+	for _, peer := range serviceNodesToEndpointList(c.orgs[0].PeerNodes, 0, tlsCA) {
+		switch {
+		case full && start < 0:
+			iter, err = client.GetNewCommittedBlocksByChannel(channelName, peer)
+		case full:
+			iter, err = client.GetCommittedBlocksByChannelFrom(channelName, uint64(start), peer)
+		case start < 0:
+			iter, err = client.GetNewCommittedFilteredBlocksByChannel(channelName, peer)
+		default:
+			iter, err = client.GetCommittedFilteredBlocksByChannelFrom(channelName, uint64(start), peer)
+		}
+		if err == nil {
+			break
+		}
+		logger.Error("Error requesting blocks", err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if iter == nil {
+		return nil, errors.New("no deliver service can be found")
+	}
+
+	stream := &EventStream{
+		iter:   iter,
+		eventC: make(chan *BlockEvent, eventBufferSize),
+		stopC:  make(chan struct{}),
+	}
+	go stream.receive(full)
+	return stream, nil
+}
+
+// Events returns the channel of block events, it's closed when the stream
+// stops, then Err tells why
+func (s *EventStream) Events() <-chan *BlockEvent {
+	return s.eventC
+}
+
+// Err ...
+func (s *EventStream) Err() error {
+	return s.err
+}
+
+// Close ...
+func (s *EventStream) Close() {
+	select {
+	case <-s.stopC:
+	default:
+		close(s.stopC)
+		s.iter.Close()
+	}
+}
+
+func (s *EventStream) receive(full bool) {
+	defer close(s.eventC)
+	for {
+		var event *BlockEvent
+		if full {
+			block, err := s.iter.NextBlock()
+			if err != nil {
+				s.err = err
+				return
+			}
+			event, err = fullBlockEvent(block)
+			if err != nil {
+				s.err = err
+				return
+			}
+		} else {
+			fblock, err := s.iter.NextFilteredBlock()
+			if err != nil {
+				s.err = err
+				return
+			}
+			event = filteredBlockEvent(fblock)
+		}
+
+		select {
+		case s.eventC <- event:
+		case <-s.stopC:
+			return
+		}
+	}
+}
+
+func fullBlockEvent(block *cb.Block) (*BlockEvent, error) {
+	info, err := DecodeBlock(block)
+	if err != nil {
+		return nil, err
+	}
+	event := &BlockEvent{
+		Number: info.Number,
+		Block:  info,
+	}
+	for _, tx := range info.Transactions {
+		for _, action := range tx.Actions {
+			if action.Event != nil {
+				event.ChaincodeEvents = append(event.ChaincodeEvents, action.Event)
+			}
+		}
+	}
+	return event, nil
+}
+
+func filteredBlockEvent(fblock *pp.FilteredBlock) *BlockEvent {
+	event := &BlockEvent{
+		Number: fblock.Number,
+	}
+	for _, tx := range fblock.FilteredTransactions {
+		event.Transactions = append(event.Transactions, &FilteredTransactionInfo{
+			TxID:           tx.Txid,
+			Type:           tx.Type.String(),
+			ValidationCode: tx.TxValidationCode.String(),
+		})
+		actions := tx.GetTransactionActions()
+		if actions == nil {
+			continue
+		}
+		for _, action := range actions.ChaincodeActions {
+			if action.ChaincodeEvent != nil {
+				event.ChaincodeEvents = append(event.ChaincodeEvents, chaincodeEventInfo(action.ChaincodeEvent))
+			}
+		}
+	}
+	return event
+}
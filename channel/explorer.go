@@ -0,0 +1,295 @@
+package channel
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"manageChain/protocols"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/core/chaincode/shim"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/ledger/rwset"
+	"github.com/hyperledger/fabric/protos/ledger/rwset/kvrwset"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	pp "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+const (
+	qsccName = "qscc"
+
+	getChainInfo       = "GetChainInfo"
+	getBlockByNumber   = "GetBlockByNumber"
+	getTransactionByID = "GetTransactionByID"
+)
+
+// ChainInfo ...
+func (c *Channel) ChainInfo(channelName string) (*ChainInfo, error) {
+	data, err := c.queryQSCC(channelName, getChainInfo)
+	if err != nil {
+		return nil, err
+	}
+	info := &cb.BlockchainInfo{}
+	if err := proto.Unmarshal(data, info); err != nil {
+		logger.Error("Error unmarshaling BlockchainInfo", err)
+		return nil, err
+	}
+	return &ChainInfo{
+		Height:            info.Height,
+		CurrentBlockHash:  hex.EncodeToString(info.CurrentBlockHash),
+		PreviousBlockHash: hex.EncodeToString(info.PreviousBlockHash),
+	}, nil
+}
+
+// Block ...
+func (c *Channel) Block(channelName string, number uint64) (*BlockInfo, error) {
+	data, err := c.queryQSCC(channelName, getBlockByNumber, strconv.FormatUint(number, 10))
+	if err != nil {
+		return nil, err
+	}
+	block := &cb.Block{}
+	if err := proto.Unmarshal(data, block); err != nil {
+		logger.Error("Error unmarshaling Block", err)
+		return nil, err
+	}
+	return DecodeBlock(block)
+}
+
+// Transaction ...
+func (c *Channel) Transaction(channelName string, txID string) (*TransactionInfo, error) {
+	data, err := c.queryQSCC(channelName, getTransactionByID, txID)
+	if err != nil {
+		return nil, err
+	}
+	ptx := &pp.ProcessedTransaction{}
+	if err := proto.Unmarshal(data, ptx); err != nil {
+		logger.Error("Error unmarshaling ProcessedTransaction", err)
+		return nil, err
+	}
+	return decodeTransaction(ptx.TransactionEnvelope, pp.TxValidationCode(ptx.ValidationCode)), nil
+}
+
+func (c *Channel) queryQSCC(channelName string, fn string, params ...string) ([]byte, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	args := [][]byte{[]byte(fn), []byte(channelName)}
+	for _, param := range params {
+		args = append(args, []byte(param))
+	}
+	peers := serviceNodesToEndpointList(c.orgs[0].PeerNodes, EndorseTimeout, c.orgs[0].OrgCA.TLSCACert())
+	_, _, resps, endorser, err := endorseOneOfList(c.orgs[0].Client, channelName, qsccName, args, nil, peers)
+	if err != nil {
+		logger.Error("Error querying qscc", err)
+		return nil, err
+	}
+	if len(resps) == 0 || resps[0].Response == nil {
+		return nil, fmt.Errorf("no qscc response from %s", endorser.Address)
+	}
+	resp := resps[0].Response
+	if resp.Status != shim.OK {
+		return nil, fmt.Errorf("bad qscc response from %s, status: %d, message: %s", endorser.Address, resp.Status, resp.Message)
+	}
+	return resp.Payload, nil
+}
+
+// DecodeBlock decodes the header and every transaction of the block, the
+// ones which can't be decoded are listed with their DecodeError
+func DecodeBlock(block *cb.Block) (*BlockInfo, error) {
+	if block.Header == nil || block.Data == nil {
+		return nil, errors.New("block header or data is missing")
+	}
+	info := &BlockInfo{
+		Number:       block.Header.Number,
+		PreviousHash: hex.EncodeToString(block.Header.PreviousHash),
+		DataHash:     hex.EncodeToString(block.Header.DataHash),
+	}
+
+	var filter []byte
+	if block.Metadata != nil && len(block.Metadata.Metadata) > int(cb.BlockMetadataIndex_TRANSACTIONS_FILTER) {
+		filter = block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	}
+	for i, data := range block.Data.Data {
+		// a block without the filter, e.g. one read from the orderers, has
+		// not been validated yet
+		code := pp.TxValidationCode_NOT_VALIDATED
+		if i < len(filter) {
+			code = pp.TxValidationCode(filter[i])
+		}
+		env, err := utils.GetEnvelopeFromBlock(data)
+		if err != nil {
+			logger.Warning("Error getting envelope %d of block %d: %s", i, block.Header.Number, err)
+			info.Transactions = append(info.Transactions, &TransactionInfo{
+				ValidationCode: code.String(),
+				DecodeError:    err.Error(),
+			})
+			continue
+		}
+		info.Transactions = append(info.Transactions, decodeTransaction(env, code))
+	}
+	return info, nil
+}
+
+// decodeTransaction decodes env as far as it can, the ledger keeps
+// transactions like the BAD_PAYLOAD ones which don't decode
+func decodeTransaction(env *cb.Envelope, code pp.TxValidationCode) *TransactionInfo {
+	tx, err := decodeEnvelope(env, code)
+	if err != nil {
+		logger.Warning("Error decoding transaction: %s", err)
+		if tx == nil {
+			tx = &TransactionInfo{ValidationCode: code.String()}
+		}
+		tx.DecodeError = err.Error()
+	}
+	return tx
+}
+
+func decodeEnvelope(env *cb.Envelope, code pp.TxValidationCode) (*TransactionInfo, error) {
+	if env == nil {
+		return nil, errors.New("transaction envelope is missing")
+	}
+	payload, err := utils.GetPayload(env)
+	if err != nil {
+		logger.Error("Error getting payload from envelope", err)
+		return nil, err
+	}
+	if payload.Header == nil {
+		return nil, errors.New("payload header is missing")
+	}
+	chdr, err := utils.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		logger.Error("Error unmarshaling channel header", err)
+		return nil, err
+	}
+	shdr, err := utils.GetSignatureHeader(payload.Header.SignatureHeader)
+	if err != nil {
+		logger.Error("Error unmarshaling signature header", err)
+		return nil, err
+	}
+
+	info := &TransactionInfo{
+		TxID:           chdr.TxId,
+		ChannelID:      chdr.ChannelId,
+		Type:           cb.HeaderType(chdr.Type).String(),
+		CreatorMSP:     creatorMSP(shdr.Creator),
+		ValidationCode: code.String(),
+	}
+	if ts := chdr.Timestamp; ts != nil {
+		info.Timestamp = time.Unix(ts.Seconds, int64(ts.Nanos)).UTC()
+	}
+
+	if cb.HeaderType(chdr.Type) != cb.HeaderType_ENDORSER_TRANSACTION {
+		return info, nil
+	}
+
+	tx, err := utils.GetTransaction(payload.Data)
+	if err != nil {
+		logger.Error("Error unmarshaling transaction", err)
+		return info, err
+	}
+	for _, action := range tx.Actions {
+		ai, err := decodeAction(action)
+		if err != nil {
+			return info, err
+		}
+		info.Actions = append(info.Actions, ai)
+	}
+	return info, nil
+}
+
+func decodeAction(action *pp.TransactionAction) (*ActionInfo, error) {
+	ccPayload, ca, err := utils.GetPayloads(action)
+	if err != nil {
+		logger.Error("Error getting payloads from action", err)
+		return nil, err
+	}
+	info := &ActionInfo{}
+	if ca.ChaincodeId != nil {
+		info.Chaincode = ca.ChaincodeId.Name
+		info.Version = ca.ChaincodeId.Version
+	}
+	if ca.Response != nil {
+		info.Status = ca.Response.Status
+		info.Message = ca.Response.Message
+		info.Payload = string(ca.Response.Payload)
+	}
+	if len(ca.Events) > 0 {
+		event := &pp.ChaincodeEvent{}
+		if err := proto.Unmarshal(ca.Events, event); err != nil {
+			logger.Error("Error unmarshaling ChaincodeEvent", err)
+			return nil, err
+		}
+		info.Event = chaincodeEventInfo(event)
+	}
+
+	cpp, err := utils.GetChaincodeProposalPayload(ccPayload.ChaincodeProposalPayload)
+	if err != nil {
+		logger.Error("Error getting chaincode proposal payload", err)
+		return nil, err
+	}
+	cis := &pp.ChaincodeInvocationSpec{}
+	if err := proto.Unmarshal(cpp.Input, cis); err != nil {
+		logger.Error("Error unmarshaling ChaincodeInvocationSpec", err)
+		return nil, err
+	}
+	if cis.ChaincodeSpec != nil && cis.ChaincodeSpec.Input != nil {
+		for _, arg := range cis.ChaincodeSpec.Input.Args {
+			info.Args = append(info.Args, string(arg))
+		}
+	}
+
+	if len(ca.Results) == 0 {
+		return info, nil
+	}
+	txRWSet := &rwset.TxReadWriteSet{}
+	if err := proto.Unmarshal(ca.Results, txRWSet); err != nil {
+		logger.Error("Error unmarshaling TxReadWriteSet", err)
+		return nil, err
+	}
+	for _, ns := range txRWSet.NsRwset {
+		kvRWSet := &kvrwset.KVRWSet{}
+		if err := proto.Unmarshal(ns.Rwset, kvRWSet); err != nil {
+			logger.Error("Error unmarshaling KVRWSet", err)
+			return nil, err
+		}
+		nsInfo := &NsRWSetInfo{Namespace: ns.Namespace}
+		for _, read := range kvRWSet.Reads {
+			ri := &ReadInfo{Key: read.Key}
+			if read.Version != nil {
+				ri.BlockNum = read.Version.BlockNum
+				ri.TxNum = read.Version.TxNum
+			}
+			nsInfo.Reads = append(nsInfo.Reads, ri)
+		}
+		for _, write := range kvRWSet.Writes {
+			nsInfo.Writes = append(nsInfo.Writes, &WriteInfo{
+				Key:      write.Key,
+				IsDelete: write.IsDelete,
+				Value:    string(write.Value),
+			})
+		}
+		info.RWSets = append(info.RWSets, nsInfo)
+	}
+	return info, nil
+}
+
+func chaincodeEventInfo(event *pp.ChaincodeEvent) *ChaincodeEventInfo {
+	return &ChaincodeEventInfo{
+		ChaincodeID: event.ChaincodeId,
+		TxID:        event.TxId,
+		EventName:   event.EventName,
+		Payload:     string(event.Payload),
+	}
+}
+
+func creatorMSP(creator []byte) string {
+	sid := &mspprotos.SerializedIdentity{}
+	if err := proto.Unmarshal(creator, sid); err != nil {
+		logger.Error("Error unmarshaling creator", err)
+		return ""
+	}
+	return sid.Mspid
+}
@@ -0,0 +1,48 @@
+package channel
+
+import (
+	"testing"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+	pp "github.com/hyperledger/fabric/protos/peer"
+	"github.com/hyperledger/fabric/protos/utils"
+)
+
+func TestDecodeBlock(t *testing.T) {
+	payload := &cb.Payload{
+		Header: &cb.Header{
+			ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{
+				Type:      int32(cb.HeaderType_ENDORSER_TRANSACTION),
+				ChannelId: "mychannel",
+				TxId:      "tx1",
+			}),
+			SignatureHeader: utils.MarshalOrPanic(&cb.SignatureHeader{}),
+		},
+		Data: []byte("not a transaction"),
+	}
+	block := cb.NewBlock(3, nil)
+	block.Data.Data = [][]byte{
+		[]byte("not an envelope"),
+		utils.MarshalOrPanic(&cb.Envelope{Payload: utils.MarshalOrPanic(payload)}),
+	}
+	block.Metadata.Metadata[cb.BlockMetadataIndex_TRANSACTIONS_FILTER] = []byte{
+		byte(pp.TxValidationCode_BAD_PAYLOAD),
+		byte(pp.TxValidationCode_BAD_PAYLOAD),
+	}
+
+	info, err := DecodeBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(info.Transactions) != 2 {
+		t.Fatalf("unexpected transactions %v", info.Transactions)
+	}
+	for _, tx := range info.Transactions {
+		if tx.DecodeError == "" || tx.ValidationCode != pp.TxValidationCode_BAD_PAYLOAD.String() {
+			t.Errorf("unexpected transaction %+v", tx)
+		}
+	}
+	if info.Transactions[1].TxID != "tx1" {
+		t.Errorf("expected the header of tx1 decoded, got %+v", info.Transactions[1])
+	}
+}
@@ -0,0 +1,365 @@
+package channel
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"manageChain/protocols"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// StartInvitation records on the public chain that the first org invites
+// the org of identity into channelName, the inviter votes Accept at once
+func (c *Channel) StartInvitation(channelName string, identity []byte) (*InvitationStatus, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	ic := &IdentityCode{}
+	if err := json.Unmarshal(identity, ic); err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	if ic.Org == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "org of identity should not be empty")
+	}
+
+	inviter := c.orgs[0].OrgName
+	args := [][]byte{
+		[]byte(startInvitation),
+		[]byte(channelName),
+		[]byte(inviter),
+		[]byte(ic.Org),
+		identity,
+	}
+	c.step("start invitation")
+	if err := c.invokePublic(args); err != nil {
+		logger.Error("Error starting invitation", err)
+		return nil, err
+	}
+	return c.VoteInvitation(channelName, inviter, ic.Org, true)
+}
+
+// VoteInvitation votes as the msp of the first org, the vote is signed by
+// its admin over the voteMessage of the invitation and Accept or Reject
+func (c *Channel) VoteInvitation(channelName string, inviter string, invitee string, accept bool) (*InvitationStatus, error) {
+	invitation, err := c.invitation(channelName, inviter, invitee)
+	if err != nil {
+		return nil, err
+	}
+	if invitation.Status != initState {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "invitation of %s is already %s", invitee, invitation.Status)
+	}
+
+	accepted := rejectState
+	if accept {
+		accepted = acceptState
+	}
+	identity, err := c.orgs[0].Client.Identity()
+	if err != nil {
+		return nil, err
+	}
+	signature, err := c.orgs[0].Client.Sign(voteMessage(channelName, invitation, accepted))
+	if err != nil {
+		logger.Error("Error signing vote", err)
+		return nil, err
+	}
+
+	args := [][]byte{
+		[]byte(signInvitation),
+		[]byte(channelName),
+		[]byte(inviter),
+		[]byte(invitee),
+		[]byte(creatorMSP(identity)),
+		[]byte(base64.StdEncoding.EncodeToString(signature)),
+		[]byte(accepted),
+	}
+	c.step("vote invitation")
+	if err := c.invokePublic(args); err != nil {
+		logger.Error("Error voting invitation", err)
+		return nil, err
+	}
+	return c.InvitationStatus(channelName, inviter, invitee)
+}
+
+// InvitationStatus tallies the votes of an invitation against the admins
+// policy of channelName, only the votes verified by verifyVotes count
+func (c *Channel) InvitationStatus(channelName string, inviter string, invitee string) (*InvitationStatus, error) {
+	invitation, err := c.invitation(channelName, inviter, invitee)
+	if err != nil {
+		return nil, err
+	}
+
+	args := [][]byte{
+		[]byte(getInvitationSignStatus),
+		[]byte(channelName),
+		[]byte(inviter),
+		[]byte(invitee),
+	}
+	data, err := c.queryPublic(args)
+	if err != nil {
+		logger.Error("Error querying invitation votes", err)
+		return nil, err
+	}
+	votes := []*ccInvitationSignStatus{}
+	if err := json.Unmarshal(data, &votes); err != nil {
+		logger.Error("Error unmarshaling invitation votes", err)
+		return nil, err
+	}
+
+	config, err := c.channelConfig(channelName)
+	if err != nil {
+		logger.Error("Error getting channel config", err)
+		return nil, err
+	}
+	members, required, err := adminsThreshold(config)
+	if err != nil {
+		return nil, err
+	}
+	votes, err = verifyVotes(config, channelName, invitation, votes, c.gm)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &InvitationStatus{
+		Invitation: invitation,
+		Votes:      votes,
+		Members:    members,
+		Required:   required,
+	}
+	for _, vote := range votes {
+		switch vote.Accepted {
+		case acceptState:
+			status.Accepted++
+		case rejectState:
+			status.Rejected++
+		}
+	}
+	switch {
+	case invitation.Status == confirmState:
+		status.Decision = InvitationConfirmed
+	case status.Accepted >= required:
+		status.Decision = InvitationAccepted
+	case members-status.Rejected < required:
+		status.Decision = InvitationRejected
+	default:
+		status.Decision = InvitationPending
+	}
+	return status, nil
+}
+
+// Invitations lists the invitations into channelName
+func (c *Channel) Invitations(channelName string) ([]*ccInvitation, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	data, err := c.queryPublic([][]byte{[]byte(getAllInvitation), []byte(channelName)})
+	if err != nil {
+		logger.Error("Error querying invitations", err)
+		return nil, err
+	}
+	invitations := []*ccInvitation{}
+	if err := json.Unmarshal(data, &invitations); err != nil {
+		logger.Error("Error unmarshaling invitations", err)
+		return nil, err
+	}
+	return invitations, nil
+}
+
+// CompleteInvitation moves an accepted invitation on as the first org. The
+// config update adding the invitee into the channel is proposed as a
+// pending update, or taken from the former proposal, and signed by the
+// first org only, the other members sign it by SignPendingUpdate with their
+// own msp. It's submitted once ready and the invitation is then confirmed.
+// The system channel is left to AddConsortiumOrg
+func (c *Channel) CompleteInvitation(channelName string, status *InvitationStatus) error {
+	if status.Decision != InvitationAccepted {
+		return protocols.Errorf(protocols.CodeBadRequest, "invitation of %s is %s", status.Invitation.Invitee, status.Decision)
+	}
+
+	inviter, invitee := status.Invitation.Inviter, status.Invitation.Invitee
+	pending := pendingUpdates().invitation(channelName, inviter, invitee)
+	if pending == nil {
+		c.step("compute config update")
+		update, err := c.addOrgConfigUpdate(channelName, []byte(status.Invitation.RawData))
+		if err != nil {
+			logger.Error("Error computing config update", err)
+			return err
+		}
+		proposed, err := c.proposeConfigUpdate(channelName, update, func(u *PendingUpdate) {
+			u.Inviter = inviter
+			u.Invitee = invitee
+		})
+		if err != nil {
+			return err
+		}
+		pending = proposed.PendingUpdate
+	}
+
+	var update *PendingUpdateStatus
+	var err error
+	if pending.State == PendingUpdateCollecting {
+		update, err = c.SignPendingUpdate(pending.ID)
+		if err == nil && update.Ready {
+			update, err = c.SubmitPendingUpdate(pending.ID)
+		}
+	} else {
+		update, err = c.PendingUpdateStatus(pending.ID)
+	}
+	if err != nil {
+		return err
+	}
+	status.PendingUpdate = update
+	if update.State != PendingUpdateSubmitted {
+		return nil
+	}
+
+	args := [][]byte{
+		[]byte(confirmInvitation),
+		[]byte(channelName),
+		[]byte(status.Invitation.Inviter),
+		[]byte(status.Invitation.Invitee),
+	}
+	c.step("confirm invitation")
+	if err := c.invokePublic(args); err != nil {
+		logger.Error("Error confirming invitation", err)
+		return err
+	}
+	status.Invitation.Status = confirmState
+	status.Decision = InvitationConfirmed
+	return nil
+}
+
+func (c *Channel) invitation(channelName string, inviter string, invitee string) (*ccInvitation, error) {
+	args := [][]byte{
+		[]byte(getInvitation),
+		[]byte(channelName),
+		[]byte(inviter),
+		[]byte(invitee),
+	}
+	data, err := c.queryPublic(args)
+	if err != nil {
+		logger.Error("Error querying invitation", err)
+		return nil, err
+	}
+	if len(data) == 0 {
+		return nil, protocols.Errorf(protocols.CodeNotFound, "invitation of %s by %s into %s not found", invitee, inviter, channelName)
+	}
+	invitation := &ccInvitation{}
+	if err := json.Unmarshal(data, invitation); err != nil {
+		logger.Error("Error unmarshaling invitation", err)
+		return nil, err
+	}
+	return invitation, nil
+}
+
+func (c *Channel) invokePublic(args [][]byte) error {
+	peers := serviceNodesToEndpointList(c.orgs[0].PeerNodes, EndorseTimeout, c.orgs[0].OrgCA.TLSCACert())
+	orderers := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, c.orgs[0].OrgCA.TLSCACert())
+	return invoke(c.orgs[0].Client, PublicChainID, PublicCCName, args, peers, orderers)
+}
+
+func (c *Channel) queryPublic(args [][]byte) ([]byte, error) {
+	peers := serviceNodesToEndpointList(c.orgs[0].PeerNodes, EndorseTimeout, c.orgs[0].OrgCA.TLSCACert())
+	return query(c.orgs[0].Client, PublicChainID, PublicCCName, args, peers)
+}
+
+// adminsThreshold returns the number of application orgs of config and how
+// many of them satisfy its admins policy
+func adminsThreshold(config *cb.Config) (members int, required int, err error) {
+	app, ok := config.ChannelGroup.Groups[channelconfig.ApplicationGroupKey]
+	if !ok {
+		return 0, 0, protocols.Errorf(protocols.CodeBadRequest, "channel has no application orgs")
+	}
+	members = len(app.Groups)
+
+	policy, ok := app.Policies[channelconfig.AdminsPolicyKey]
+	if !ok || policy.Policy == nil || policy.Policy.Type != int32(cb.Policy_IMPLICIT_META) {
+		return 0, 0, protocols.Errorf(protocols.CodeBadRequest, "admins policy of channel is not an implicit meta policy, votes can't be tallied")
+	}
+	meta := &cb.ImplicitMetaPolicy{}
+	if err := proto.Unmarshal(policy.Policy.Value, meta); err != nil {
+		return 0, 0, err
+	}
+	switch meta.Rule {
+	case cb.ImplicitMetaPolicy_ANY:
+		required = 1
+	case cb.ImplicitMetaPolicy_ALL:
+		required = members
+	default:
+		required = members/2 + 1
+	}
+	return members, required, nil
+}
+
+// verifyVotes keeps the votes signed by an admin of an application org of
+// config over the invitation and their decision, the first one of every msp
+// only. Anyone may write a vote on the public chain, the others are dropped
+func verifyVotes(config *cb.Config, channelName string, invitation *ccInvitation, votes []*ccInvitationSignStatus, gm bool) ([]*ccInvitationSignStatus, error) {
+	msps, err := groupMSPs(config, channelconfig.ApplicationGroupKey)
+	if err != nil {
+		return nil, err
+	}
+	verified := []*ccInvitationSignStatus{}
+	voted := make(map[string]bool)
+	for _, vote := range votes {
+		if voted[vote.Signer] {
+			continue
+		}
+		mspConfig, ok := msps[vote.Signer]
+		if !ok {
+			logger.Warning("Dropping vote of %s, not a member of the channel", vote.Signer)
+			continue
+		}
+		if err := verifyVote(mspConfig, channelName, invitation, vote, gm); err != nil {
+			logger.Warning("Dropping vote of %s: %s", vote.Signer, err)
+			continue
+		}
+		voted[vote.Signer] = true
+		verified = append(verified, vote)
+	}
+	return verified, nil
+}
+
+// verifyVote checks the vote is signed by one of the admins of mspConfig,
+// the votes carry no identity so every admin cert is tried
+func verifyVote(mspConfig *mspprotos.MSPConfig, channelName string, invitation *ccInvitation, vote *ccInvitationSignStatus, gm bool) error {
+	signature, err := base64.StdEncoding.DecodeString(vote.Signature)
+	if err != nil {
+		return err
+	}
+	fmsp, err := fabricMSPConfig(mspConfig)
+	if err != nil {
+		return err
+	}
+	msg := voteMessage(channelName, invitation, vote.Accepted)
+	for _, cert := range fmsp.Admins {
+		identity, err := proto.Marshal(&mspprotos.SerializedIdentity{Mspid: vote.Signer, IdBytes: cert})
+		if err != nil {
+			return err
+		}
+		if sdk.VerifySignature(mspConfig, identity, msg, signature, gm) == nil {
+			return nil
+		}
+	}
+	return errors.New("signature matches no admin")
+}
+
+// voteMessage is what a vote signs, the channel, the parties and the time
+// of the invitation bind it, so it can't be replayed on another invitation
+// with the same raw data
+func voteMessage(channelName string, invitation *ccInvitation, accepted string) []byte {
+	msg, _ := json.Marshal([]string{
+		channelName,
+		invitation.Inviter,
+		invitation.Invitee,
+		strconv.FormatInt(invitation.InviteTime, 10),
+		invitation.RawData,
+		accepted,
+	})
+	return msg
+}
@@ -0,0 +1,76 @@
+package channel
+
+import (
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+func TestVerifyVotes(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	config := &cb.Config{ChannelGroup: cb.NewConfigGroup()}
+	app := cb.NewConfigGroup()
+	config.ChannelGroup.Groups[channelconfig.ApplicationGroupKey] = app
+	clients := make(map[string]*sdk.Client)
+	for _, org := range []string{"testorg1", "testorg2", "outsider"} {
+		orgCA, err := sdk.NewCA(path.Join(dir, org), org)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if clients[org], err = sdk.NewClient(orgCA.AdminCommonName(), org, orgCA.AdminMSPDir(), false); err != nil {
+			t.Fatal(err)
+		}
+		if org == "outsider" {
+			continue
+		}
+		if app.Groups[org], err = sdk.NewConsortiumOrgGroup(&sdk.Organization{Name: org, ID: org, MSPDir: orgCA.MSPDir()}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	invitation := &ccInvitation{Inviter: "testorg1", Invitee: "testorg3", InviteTime: 1, RawData: `{"Org":"testorg3"}`}
+	signedVote := func(channelName string, invitation *ccInvitation, signer string, by string, accepted string) *ccInvitationSignStatus {
+		signature, err := clients[by].Sign(voteMessage(channelName, invitation, accepted))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return &ccInvitationSignStatus{Signer: signer, Signature: base64.StdEncoding.EncodeToString(signature), Accepted: accepted}
+	}
+	vote := func(signer string, by string, accepted string) *ccInvitationSignStatus {
+		return signedVote("testchannel", invitation, signer, by, accepted)
+	}
+	otherInviter := *invitation
+	otherInviter.Inviter = "testorg2"
+	reinvited := *invitation
+	reinvited.InviteTime = 2
+	tampered := vote("testorg2", "testorg2", rejectState)
+	tampered.Accepted = acceptState
+
+	votes, err := verifyVotes(config, "testchannel", invitation, []*ccInvitationSignStatus{
+		vote("testorg1", "testorg1", acceptState),
+		vote("testorg1", "testorg1", acceptState),
+		vote("testorg2", "outsider", acceptState),
+		vote("outsider", "outsider", acceptState),
+		tampered,
+		signedVote("otherchannel", invitation, "testorg2", "testorg2", acceptState),
+		signedVote("testchannel", &otherInviter, "testorg2", "testorg2", acceptState),
+		signedVote("testchannel", &reinvited, "testorg2", "testorg2", acceptState),
+	}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(votes) != 1 || votes[0].Signer != "testorg1" {
+		t.Fatalf("expected only the vote of testorg1, got %v", votes)
+	}
+}
@@ -0,0 +1,184 @@
+package channel
+
+import (
+	"encoding/json"
+	"manageChain/protocols"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/msp"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// IssueInviteCode returns an invite code of channelName signed by the first
+// org and valid for ttl. The invited org should already be added into the
+// channel, the code only spares it asking the orderers out of band
+func (c *Channel) IssueInviteCode(channelName string, ttl time.Duration) (*InviteCode, error) {
+	if channelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	if ttl <= 0 {
+		ttl = DefaultInviteCodeTTL
+	}
+
+	genesis, err := c.genesisBlock(channelName)
+	if err != nil {
+		logger.Error("Error getting genesis block", err)
+		return nil, err
+	}
+	configBlock, err := c.configBlock(channelName)
+	if err != nil {
+		logger.Error("Error getting config block", err)
+		return nil, err
+	}
+	config, err := configFromBlock(configBlock)
+	if err != nil {
+		return nil, err
+	}
+	orderers, tlsCACerts, err := ordererInfo(config)
+	if err != nil {
+		logger.Error("Error getting orderers of channel", err)
+		return nil, err
+	}
+	identity, err := c.orgs[0].Client.Identity()
+	if err != nil {
+		return nil, err
+	}
+
+	code := &InviteCode{
+		ChannelName:       channelName,
+		Orderers:          orderers,
+		OrdererTLSCACerts: tlsCACerts,
+		Inviter:           c.orgs[0].OrgName,
+		InviterIdentity:   identity,
+		ExpiresAt:         time.Now().Add(ttl).Unix(),
+	}
+	if code.ChannelGenesisBlock, err = proto.Marshal(genesis); err != nil {
+		return nil, err
+	}
+	if code.ConfigBlock, err = proto.Marshal(configBlock); err != nil {
+		return nil, err
+	}
+
+	msg, err := json.Marshal(code)
+	if err != nil {
+		return nil, err
+	}
+	if code.Signature, err = c.orgs[0].Client.Sign(msg); err != nil {
+		logger.Error("Error signing invite code", err)
+		return nil, err
+	}
+	return code, nil
+}
+
+// RedeemInviteCode checks data is an unexpired invite code signed by a
+// member of the channel issued by one of trustedCAs, then joins all peers
+// of the first org
+func (c *Channel) RedeemInviteCode(data []byte, trustedCAs [][]byte) (*OrgJoinChannelResponse, error) {
+	code := &InviteCode{}
+	if err := json.Unmarshal(data, code); err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	if time.Now().Unix() >= code.ExpiresAt {
+		return nil, protocols.Errorf(protocols.CodeExpired, "invite code of %s expired at %s", code.ChannelName, time.Unix(code.ExpiresAt, 0))
+	}
+
+	c.step("verify invite code")
+	genesis, err := c.verifyInviteCode(code, trustedCAs)
+	if err != nil {
+		logger.Error("Error verifying invite code", err)
+		return nil, err
+	}
+
+	if err := c.join(code.ChannelName, genesis); err != nil {
+		logger.Error("Error joining channel", err)
+		return nil, err
+	}
+
+	resp := &OrgJoinChannelResponse{
+		ChannelName:       code.ChannelName,
+		Orderers:          code.Orderers,
+		OrdererTLSCACerts: code.OrdererTLSCACerts,
+	}
+	for _, peer := range c.orgs[0].PeerNodes {
+		resp.Peers = append(resp.Peers, peer.Endpoint)
+	}
+	return resp, nil
+}
+
+// verifyInviteCode returns the genesis block of code once its signature is
+// checked against trustedCAs and the inviter is found a member in the
+// config block. The blocks come with the code, so the trust is anchored in
+// the pinned CAs only and the blocks are vouched for by the signature
+func (c *Channel) verifyInviteCode(code *InviteCode, trustedCAs [][]byte) (*cb.Block, error) {
+	if len(trustedCAs) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "no trusted inviter ca is given to check the invite code")
+	}
+	genesis := &cb.Block{}
+	if err := proto.Unmarshal(code.ChannelGenesisBlock, genesis); err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	configBlock := &cb.Block{}
+	if err := proto.Unmarshal(code.ConfigBlock, configBlock); err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	if genesis.Header == nil || genesis.Header.Number != 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "invite code carries no genesis block")
+	}
+	for _, block := range []*cb.Block{genesis, configBlock} {
+		chainID, err := blockChannelID(block)
+		if err != nil {
+			return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+		}
+		if chainID != code.ChannelName {
+			return nil, protocols.Errorf(protocols.CodeBadRequest, "invite code of %s carries a block of %s", code.ChannelName, chainID)
+		}
+	}
+
+	config, err := configFromBlock(configBlock)
+	if err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	msps, err := groupMSPs(config, channelconfig.ApplicationGroupKey)
+	if err != nil {
+		return nil, err
+	}
+	mspID := creatorMSP(code.InviterIdentity)
+	if _, ok := msps[mspID]; !ok {
+		return nil, protocols.Errorf(protocols.CodeForbidden, "inviter %s is not a member of %s", mspID, code.ChannelName)
+	}
+	pinned, err := pinnedMSP(mspID, trustedCAs)
+	if err != nil {
+		return nil, err
+	}
+
+	signature := code.Signature
+	code.Signature = nil
+	msg, err := json.Marshal(code)
+	code.Signature = signature
+	if err != nil {
+		return nil, err
+	}
+	if err := sdk.VerifySignature(pinned, code.InviterIdentity, msg, signature, c.gm); err != nil {
+		return nil, protocols.Errorf(protocols.CodeForbidden, "bad signature of invite code: %s", err)
+	}
+	return genesis, nil
+}
+
+// pinnedMSP is the msp mspID trusting rootCerts only
+func pinnedMSP(mspID string, rootCerts [][]byte) (*mspprotos.MSPConfig, error) {
+	config, err := proto.Marshal(&mspprotos.FabricMSPConfig{
+		Name:      mspID,
+		RootCerts: rootCerts,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &mspprotos.MSPConfig{
+		Type:   int32(msp.FABRIC),
+		Config: config,
+	}, nil
+}
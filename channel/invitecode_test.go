@@ -0,0 +1,92 @@
+package channel
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+func TestVerifyInviteCode(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	inviterCA, err := sdk.NewCA(path.Join(dir, "inviterorg"), "inviterorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherCA, err := sdk.NewCA(path.Join(dir, "otherorg"), "otherorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	inviter, err := sdk.NewClient(inviterCA.AdminCommonName(), "inviterorg", inviterCA.AdminMSPDir(), false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	config := &cb.Config{ChannelGroup: cb.NewConfigGroup()}
+	app := cb.NewConfigGroup()
+	config.ChannelGroup.Groups[channelconfig.ApplicationGroupKey] = app
+	if app.Groups["inviterorg"], err = sdk.NewConsortiumOrgGroup(&sdk.Organization{Name: "inviterorg", ID: "inviterorg", MSPDir: inviterCA.MSPDir()}); err != nil {
+		t.Fatal(err)
+	}
+	payload := &cb.Payload{
+		Header: &cb.Header{
+			ChannelHeader: utils.MarshalOrPanic(&cb.ChannelHeader{
+				Type:      int32(cb.HeaderType_CONFIG),
+				ChannelId: "channel1",
+			}),
+		},
+		Data: utils.MarshalOrPanic(&cb.ConfigEnvelope{Config: config}),
+	}
+	block := cb.NewBlock(0, nil)
+	block.Data.Data = [][]byte{utils.MarshalOrPanic(&cb.Envelope{Payload: utils.MarshalOrPanic(payload)})}
+
+	identity, err := inviter.Identity()
+	if err != nil {
+		t.Fatal(err)
+	}
+	code := &InviteCode{
+		ChannelName:         "channel1",
+		ChannelGenesisBlock: utils.MarshalOrPanic(block),
+		ConfigBlock:         utils.MarshalOrPanic(block),
+		Inviter:             "inviterorg",
+		InviterIdentity:     identity,
+		ExpiresAt:           time.Now().Add(time.Hour).Unix(),
+	}
+	msg, err := json.Marshal(code)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if code.Signature, err = inviter.Sign(msg); err != nil {
+		t.Fatal(err)
+	}
+
+	inviterRoot, err := inviterCA.RootCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherRoot, err := otherCA.RootCert()
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &Channel{}
+	if _, err := c.verifyInviteCode(code, [][]byte{otherRoot, inviterRoot}); err != nil {
+		t.Fatal(err)
+	}
+	for _, trusted := range [][][]byte{nil, {otherRoot}} {
+		if _, err := c.verifyInviteCode(code, trusted); err == nil {
+			t.Errorf("expected invite code refused when trusting %d other cas", len(trusted))
+		}
+	}
+}
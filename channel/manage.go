@@ -3,14 +3,14 @@ package channel
 import (
 	"encoding/json"
 	"errors"
-	"io/ioutil"
+	"manageChain/protocols"
 	"os"
 	"path"
 	"strings"
 
 	"github.com/astaxie/beego"
 	cb "github.com/hyperledger/fabric/protos/common"
-	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/hyperledger/fabric/protos/orderer/etcdraft"
 	"github.com/hyperledger/fabric/sdk"
 )
 
@@ -19,7 +19,10 @@ const (
 )
 
 const (
-	defaultConsensusType = "kafka"
+	soloConsensusType     = sdk.OrdererTypeSolo
+	kafkaConsensusType    = sdk.OrdererTypeKafka
+	etcdraftConsensusType = sdk.OrdererTypeEtcdRaft
+	defaultConsensusType  = kafkaConsensusType
 )
 
 func (c *Channel) IdentityCode() (*IdentityCode, error) {
@@ -46,36 +49,18 @@ func (c *Channel) IdentityCode() (*IdentityCode, error) {
 
 func (c *Channel) AddOrg(identity []byte, operateOrg []*OrgInfo, channelName string) error {
 	logger.Info("start add org")
-	ic := &IdentityCode{}
-	if err := json.Unmarshal(identity, ic); err != nil {
-		logger.Error("error unmarshal", err)
-		return err
-	}
-	logger.Info("mspdata:%s", ic.OrgMSP)
-	mspDir, mspID, err := sdk.WriteMSPDir(tmpMSPDir, ic.OrgMSP)
+	ic, peerOrgs, ordererOrgs, err := identityOrgs(identity)
 	if err != nil {
-		logger.Error("error writing certs to msp dir", err)
 		return err
 	}
 
 	broadcasters := serviceNodesToEndpointList(operateOrg[0].OrdererNodes, CreateChannelTimeout, operateOrg[0].OrgCA.TLSCACert())
 
-	peerOrgs := []*sdk.Organization{&sdk.Organization{
-		Name:        mspID,
-		ID:          mspID,
-		MSPDir:      mspDir,
-		AnchorPeers: ic.Anchors,
-	}}
-
-	ordererOrgs := []*sdk.Organization{&sdk.Organization{
-		Name:   mspID,
-		ID:     mspID,
-		MSPDir: mspDir,
-	}}
 	consortiumOrgs := make(map[string][]*sdk.Organization)
-	consortiumOrgs[DefaultConsortium] = peerOrgs
+	consortiumOrgs[c.network.consortium()] = peerOrgs
 
-	systemUpdate, err := c.createAddOrgChannelConfigUpdate(sdk.DefaultSystemChainID, nil, ordererOrgs, consortiumOrgs, ic.Orderers, broadcasters)
+	c.step("compute config update")
+	systemUpdate, err := c.createAddOrgChannelConfigUpdate(c.network.systemChannel(), nil, ordererOrgs, consortiumOrgs, ic.Orderers, broadcasters)
 	if err != nil {
 		logger.Error("Error create system channel config update", err)
 		return err
@@ -87,56 +72,46 @@ func (c *Channel) AddOrg(identity []byte, operateOrg []*OrgInfo, channelName str
 	}
 
 	//sign
-	systemSigs := []*cb.ConfigSignature{}
-	channelSigs := []*cb.ConfigSignature{}
-	for _, org := range operateOrg {
-		sSigHeader, sSignedSigHeader, err := org.Client.SignChannelConfigUpdate(systemUpdate)
-		if err != nil {
-			logger.Error("Error signing system config update", err)
-			return err
-		}
-		cSigHeader, cSignedSigHeader, err := org.Client.SignChannelConfigUpdate(channelUpdate)
-		if err != nil {
-			logger.Error("Error signing channel config update", err)
-			return err
-		}
-
-		systemSigs = append(systemSigs, &cb.ConfigSignature{
-			SignatureHeader: sSigHeader,
-			Signature:       sSignedSigHeader,
-		})
-
-		channelSigs = append(channelSigs, &cb.ConfigSignature{
-			SignatureHeader: cSigHeader,
-			Signature:       cSignedSigHeader,
-		})
-
+	c.step("sign config update")
+	systemSigs, err := signConfigUpdate(operateOrg, systemUpdate)
+	if err != nil {
+		logger.Error("Error signing system config update", err)
+		return err
+	}
+	channelSigs, err := signConfigUpdate(operateOrg, channelUpdate)
+	if err != nil {
+		logger.Error("Error signing channel config update", err)
+		return err
 	}
 
+	c.step("broadcast config update")
+	failed := protocols.Errorf(protocols.CodeBroadcastFailed, "failed updating system channel after try all orderers")
 	for _, broadcaster := range broadcasters {
-		err = operateOrg[0].Client.UpdateChannelByConfigUpdate(sdk.DefaultSystemChainID, systemUpdate, systemSigs, broadcaster)
+		err = operateOrg[0].Client.UpdateChannelByConfigUpdate(c.network.systemChannel(), systemUpdate, systemSigs, broadcaster)
 		if err != nil {
 			logger.Error("Error update system channel", err)
+			failed.AddDetail(broadcaster.Address, err)
 			continue
 		}
 		err = operateOrg[0].Client.UpdateChannelByConfigUpdate(channelName, channelUpdate, channelSigs, broadcaster)
 		if err != nil {
 			logger.Error("Error update channel", err)
-			return err
+			return protocols.Errorf(protocols.CodeBroadcastFailed, "failed updating channel %s", channelName).AddDetail(broadcaster.Address, err)
 		}
 		logger.Info("Suceesfully add new org")
 		return nil
 	}
 
 	logger.Info("end add new org.")
-	return nil
+	return failed
 }
 
 func (c *Channel) DeleteOrg(delOrg string, delOrderers []string, channelName string, operateOrg []*OrgInfo) error {
 	logger.Info("start delete org.")
 	broadcasters := serviceNodesToEndpointList(operateOrg[0].OrdererNodes, CreateChannelTimeout, operateOrg[0].OrgCA.TLSCACert())
 
-	systemUpdate, err := c.createDelOrgChannelConfigUpdate(sdk.DefaultSystemChainID, delOrg, delOrderers, broadcasters)
+	c.step("compute config update")
+	systemUpdate, err := c.createDelOrgChannelConfigUpdate(c.network.systemChannel(), delOrg, delOrderers, broadcasters)
 	if err != nil {
 		logger.Error("Error create system channel config update", err)
 		return err
@@ -150,46 +125,128 @@ func (c *Channel) DeleteOrg(delOrg string, delOrderers []string, channelName str
 	logger.Info("channelUpdate:%s", channelUpdate)
 
 	// sign
-	systemSigs := []*cb.ConfigSignature{}
-	channelSigs := []*cb.ConfigSignature{}
-	for _, org := range operateOrg {
-		sSigHeader, sSignedSigHeader, err := org.Client.SignChannelConfigUpdate(systemUpdate)
-		if err != nil {
-			logger.Error("Error signing system config update", err)
-			return err
-		}
-		cSigHeader, cSignedSigHeader, err := org.Client.SignChannelConfigUpdate(channelUpdate)
-		if err != nil {
-			logger.Error("Error signing channel config update", err)
-		}
-
-		systemSigs = append(systemSigs, &cb.ConfigSignature{
-			SignatureHeader: sSigHeader,
-			Signature:       sSignedSigHeader,
-		})
-
-		channelSigs = append(channelSigs, &cb.ConfigSignature{
-			SignatureHeader: cSigHeader,
-			Signature:       cSignedSigHeader,
-		})
+	c.step("sign config update")
+	systemSigs, err := signConfigUpdate(operateOrg, systemUpdate)
+	if err != nil {
+		logger.Error("Error signing system config update", err)
+		return err
+	}
+	channelSigs, err := signConfigUpdate(operateOrg, channelUpdate)
+	if err != nil {
+		logger.Error("Error signing channel config update", err)
+		return err
 	}
 
+	c.step("broadcast config update")
+	failed := protocols.Errorf(protocols.CodeBroadcastFailed, "failed updating system channel after try all orderers")
 	for _, broadcaster := range broadcasters {
-		err := operateOrg[0].Client.UpdateChannelByConfigUpdate(sdk.DefaultSystemChainID, systemUpdate, systemSigs, broadcaster)
+		err := operateOrg[0].Client.UpdateChannelByConfigUpdate(c.network.systemChannel(), systemUpdate, systemSigs, broadcaster)
 		if err != nil {
 			logger.Error("Error update system channel", err)
+			failed.AddDetail(broadcaster.Address, err)
 			continue
 		}
 
 		err = operateOrg[0].Client.UpdateChannelByConfigUpdate(channelName, channelUpdate, channelSigs, broadcaster)
 		if err != nil {
 			logger.Error("Error update channel ", err)
-			return err
+			return protocols.Errorf(protocols.CodeBroadcastFailed, "failed updating channel %s", channelName).AddDetail(broadcaster.Address, err)
 		}
 		logger.Info("Succeesfully delete org.")
+		return nil
 	}
 	logger.Info("end delete org.")
-	return nil
+	return failed
+}
+
+// PreviewAddOrg computes what AddOrg would change in the system channel
+// and in channelName, nothing is signed nor broadcast
+func (c *Channel) PreviewAddOrg(identity []byte, channelName string) ([]*ConfigUpdatePreview, error) {
+	ic, peerOrgs, ordererOrgs, err := identityOrgs(identity)
+	if err != nil {
+		return nil, err
+	}
+	consortiumOrgs := make(map[string][]*sdk.Organization)
+	consortiumOrgs[c.network.consortium()] = peerOrgs
+
+	c.step("compute config update")
+	system, err := c.previewAddOrg(c.network.systemChannel(), nil, ordererOrgs, consortiumOrgs, ic.Orderers)
+	if err != nil {
+		logger.Error("Error previewing system channel config update", err)
+		return nil, err
+	}
+	channel, err := c.previewAddOrg(channelName, peerOrgs, ordererOrgs, nil, ic.Orderers)
+	if err != nil {
+		logger.Error("Error previewing channel config update", err)
+		return nil, err
+	}
+	return []*ConfigUpdatePreview{system, channel}, nil
+}
+
+// PreviewDeleteOrg computes what DeleteOrg would change in the system
+// channel and in channelName, nothing is signed nor broadcast
+func (c *Channel) PreviewDeleteOrg(delOrg string, delOrderers []string, channelName string) ([]*ConfigUpdatePreview, error) {
+	c.step("compute config update")
+	var previews []*ConfigUpdatePreview
+	for _, chainID := range []string{c.network.systemChannel(), channelName} {
+		block, err := c.configBlock(chainID)
+		if err != nil {
+			return nil, err
+		}
+		original, updated, err := c.orgs[0].Client.GetDelOrgChannelConfig(chainID, block, delOrg, delOrderers, c.network.consortium())
+		if err != nil {
+			logger.Error("Error computing config of chain %s: %s", chainID, err)
+			return nil, err
+		}
+		preview, err := previewConfigUpdate(chainID, original, updated)
+		if err != nil {
+			return nil, err
+		}
+		previews = append(previews, preview)
+	}
+	return previews, nil
+}
+
+func (c *Channel) previewAddOrg(chainID string, peerOrgs, ordererOrgs []*sdk.Organization, consortiumOrgs map[string][]*sdk.Organization, orderers []string) (*ConfigUpdatePreview, error) {
+	block, err := c.configBlock(chainID)
+	if err != nil {
+		return nil, err
+	}
+	original, updated, err := c.orgs[0].Client.GetAddOrgChannelConfig(block, ordererOrgs, peerOrgs, consortiumOrgs, orderers)
+	if err != nil {
+		return nil, err
+	}
+	return previewConfigUpdate(chainID, original, updated)
+}
+
+// identityOrgs writes the msp carried by an identity code into tmpMSPDir
+// and returns the org as a peer org and as an orderer org
+func identityOrgs(identity []byte) (*IdentityCode, []*sdk.Organization, []*sdk.Organization, error) {
+	ic := &IdentityCode{}
+	if err := json.Unmarshal(identity, ic); err != nil {
+		logger.Error("error unmarshal", err)
+		return nil, nil, nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	logger.Info("mspdata:%s", ic.OrgMSP)
+	mspDir, mspID, err := sdk.WriteMSPDir(tmpMSPDir, ic.OrgMSP)
+	if err != nil {
+		logger.Error("error writing certs to msp dir", err)
+		return nil, nil, nil, err
+	}
+
+	peerOrgs := []*sdk.Organization{&sdk.Organization{
+		Name:        mspID,
+		ID:          mspID,
+		MSPDir:      mspDir,
+		AnchorPeers: ic.Anchors,
+	}}
+
+	ordererOrgs := []*sdk.Organization{&sdk.Organization{
+		Name:   mspID,
+		ID:     mspID,
+		MSPDir: mspDir,
+	}}
+	return ic, peerOrgs, ordererOrgs, nil
 }
 
 func (c *Channel) createAddOrgChannelConfigUpdate(chainID string, peerOrgs, ordererOrgs []*sdk.Organization, consortiumOrgs map[string][]*sdk.Organization, orderers []string, casters []*sdk.Endpoint) ([]byte, error) {
@@ -215,13 +272,28 @@ func (c Channel) createDelOrgChannelConfigUpdate(chainID string, delOrg string,
 			continue
 		}
 		logger.Info("Successfully getting config block from chain %s", chainID)
-		return c.orgs[0].Client.GetDelOrgChannelConfigUpdate(chainID, configBlock, delOrg, delOrderers)
+		return c.orgs[0].Client.GetDelOrgChannelConfigUpdate(chainID, configBlock, delOrg, delOrderers, c.network.consortium())
 	}
 	logger.Info("end createDelOrgChannelConfigUpdate.")
 	return nil, errors.New("failed getDelOrgChannelConfigUpdate after try all orderers")
 }
 
-func GenerateCrypto(orgs []*OrgInfo) error {
+// GenerateCrypto generates the msps of orgs and their nodes and issues
+// users, the client identities of each org by name
+func GenerateCrypto(orgs []*OrgInfo, users map[string][]*UserConfig) error {
+	names := make(map[string]bool)
+	for _, org := range orgs {
+		names[org.OrgName] = true
+	}
+	for name, orgUsers := range users {
+		if !names[name] {
+			return protocols.Errorf(protocols.CodeBadRequest, "users of org %s which is not in orgs", name)
+		}
+		if err := validateUsers(orgUsers); err != nil {
+			return err
+		}
+	}
+
 	mspDir := beego.AppConfig.String("MSPDir")
 	// gm, _ := beego.AppConfig.Bool("GM")
 	var orginfos []*OrgInfo
@@ -264,6 +336,10 @@ func GenerateCrypto(orgs []*OrgInfo) error {
 				return err
 			}
 		}
+		//users
+		if _, err := GenerateUsers(org.OrgCA, users[org.OrgName]); err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -299,11 +375,39 @@ func filterSAN(san []string) (ret []string) {
 	return
 }
 
-func GenGenesisBlock(orgs []*OrgInfo, kafkas []string) (*cb.Block, error) {
+// GenGenesisBlock generates the genesis block of the system channel of
+// req, whose orgs have their OrgCA loaded. All orgs are orderer orgs and,
+// unless req.Consortiums tells otherwise, members of the consortium of req
+func GenGenesisBlock(req *GenGenesisBlockRequest) (*cb.Block, error) {
+	orgs, ordererType, kafkas := req.Orgs, req.OrdererType, req.Kafkas
+	if ordererType == "" {
+		ordererType = defaultConsensusType
+	}
+	var consenters []*etcdraft.Consenter
+	var raftOptions etcdraft.Options
+	switch ordererType {
+	case kafkaConsensusType:
+	case soloConsensusType, etcdraftConsensusType:
+		if len(kafkas) != 0 {
+			return nil, protocols.Errorf(protocols.CodeBadRequest, "%s orderers don't use kafka brokers", ordererType)
+		}
+		if ordererType == soloConsensusType {
+			break
+		}
+		var err error
+		if raftOptions, err = req.EtcdRaft.options(); err != nil {
+			return nil, err
+		}
+		if consenters, err = raftConsenters(orgs); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "unknown orderer type %s, should be %s, %s or %s", ordererType, soloConsensusType, kafkaConsensusType, etcdraftConsensusType)
+	}
 
 	var orderers []string
-	var peerOrgs []*sdk.Organization
 	var ordererOrgs []*sdk.Organization
+	peerOrgs := make(map[string]*sdk.Organization)
 
 	for _, org := range orgs {
 		for _, orderer := range org.OrdererNodes {
@@ -313,39 +417,73 @@ func GenGenesisBlock(orgs []*OrgInfo, kafkas []string) (*cb.Block, error) {
 	logger.Info("orderers:", orderers)
 
 	for _, org := range orgs {
+		policies, err := orgPolicies(org.OrgMSP, org.Policies)
+		if err != nil {
+			return nil, err
+		}
 		peerOrg := &sdk.Organization{
-			Name:   org.OrgMSP,
-			ID:     org.OrgMSP,
-			MSPDir: org.OrgCA.MSPDir(),
+			Name:     org.OrgMSP,
+			ID:       org.OrgMSP,
+			MSPDir:   org.OrgCA.MSPDir(),
+			Policies: policies,
 		}
 		ordererOrg := &sdk.Organization{
-			Name:   org.OrgMSP,
-			ID:     org.OrgMSP,
-			MSPDir: org.OrgCA.MSPDir(),
+			Name:     org.OrgMSP,
+			ID:       org.OrgMSP,
+			MSPDir:   org.OrgCA.MSPDir(),
+			Policies: policies,
 		}
-		peerOrgs = append(peerOrgs, peerOrg)
+		peerOrgs[org.OrgName] = peerOrg
 		ordererOrgs = append(ordererOrgs, ordererOrg)
 	}
 	logger.Info("peerorgs,ordererorgs:", peerOrgs, ordererOrgs)
 
-	conf := &sdk.GenesisConfig{
-		ChainID:                 sdk.DefaultSystemChainID,
-		OrdererType:             defaultConsensusType,
-		Addresses:               orderers,
-		AdminsPolicy:            sdk.PolicyMajorityAdmins,
-		WritersPolicy:           sdk.PolicyAnyWriters,
-		ReadersPolicy:           sdk.PolicyAnyReaders,
-		OrdererOrganizations:    ordererOrgs,
-		ConsortiumOrganizations: peerOrgs,
-		ConsortiumName:          sdk.DefaultConsortium,
-		KafkaBrokers:            kafkas,
+	members := req.Consortiums
+	if len(members) == 0 {
+		var all []string
+		for _, org := range orgs {
+			all = append(all, org.OrgName)
+		}
+		members = map[string][]string{req.consortium(): all}
+	}
+	consortiums := make(map[string][]*sdk.Organization)
+	for name, orgNames := range members {
+		consortiums[name] = []*sdk.Organization{}
+		for _, orgName := range orgNames {
+			peerOrg, ok := peerOrgs[orgName]
+			if !ok {
+				return nil, protocols.Errorf(protocols.CodeBadRequest, "org %s of consortium %s is not in orgs", orgName, name)
+			}
+			consortiums[name] = append(consortiums[name], peerOrg)
+		}
 	}
-	logger.Info("genesis block conf:", conf)
-	block := sdk.CreateGenesisBlock(conf)
 
-	err := ioutil.WriteFile("orderer.block", utils.MarshalOrPanic(block), 0644)
+	conf := &sdk.GenesisConfig{
+		ChainID:              req.systemChannel(),
+		OrdererType:          ordererType,
+		Addresses:            orderers,
+		AdminsPolicy:         sdk.PolicyMajorityAdmins,
+		WritersPolicy:        sdk.PolicyAnyWriters,
+		ReadersPolicy:        sdk.PolicyAnyReaders,
+		OrdererOrganizations: ordererOrgs,
+		Consortiums:          consortiums,
+		KafkaBrokers:         kafkas,
+		Consenters:           consenters,
+		RaftOptions:          raftOptions,
+	}
+	timeout, err := req.BatchConfig.timeout()
 	if err != nil {
-		logger.Info("write file err:", err)
+		return nil, err
+	}
+	conf.BatchTimeout = timeout
+	conf.MaxMessageCount = req.MaxMessageCount
+	conf.AbsoluteMaxBytes = req.AbsoluteMaxBytes
+	conf.PreferredMaxBytes = req.PreferredMaxBytes
+	if err := req.Capabilities.validate(); err != nil {
+		return nil, err
 	}
-	return block, nil
+	conf.ChannelCapability = req.Capabilities.Channel
+	conf.OrdererCapability = req.Capabilities.Orderer
+	logger.Info("genesis block conf:", conf)
+	return sdk.CreateGenesisBlock(conf), nil
 }
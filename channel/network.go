@@ -0,0 +1,114 @@
+package channel
+
+import (
+	"manageChain/protocols"
+
+	"github.com/astaxie/beego"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/tools/configtxgen/encoder"
+	"github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// SetNetwork sets the system channel and the consortium the operations of
+// c work with, the defaults of NetworkConfig if nil
+func (c *Channel) SetNetwork(network *NetworkConfig) {
+	c.network = network
+}
+
+// systemChannel returns the SystemChannel of n, or the one of app.conf, or
+// the sdk default
+func (n *NetworkConfig) systemChannel() string {
+	if n != nil && n.SystemChannel != "" {
+		return n.SystemChannel
+	}
+	return beego.AppConfig.DefaultString("SystemChannel", sdk.DefaultSystemChainID)
+}
+
+// consortium returns the Consortium of n, or the one of app.conf, or the
+// sdk default
+func (n *NetworkConfig) consortium() string {
+	if n != nil && n.Consortium != "" {
+		return n.Consortium
+	}
+	return beego.AppConfig.DefaultString("Consortium", DefaultConsortium)
+}
+
+// AddConsortiumOrg adds the org of identity to the consortium of c in the
+// system channel, creating the consortium if it doesn't exist. No
+// application channel is touched
+func (c *Channel) AddConsortiumOrg(identity []byte, dryRun bool) (*ConfigUpdatePreview, error) {
+	_, peerOrgs, _, err := identityOrgs(identity)
+	if err != nil {
+		return nil, err
+	}
+	group, err := sdk.NewConsortiumOrgGroup(peerOrgs[0])
+	if err != nil {
+		return nil, err
+	}
+	name := c.network.consortium()
+	return c.updateConfig(c.network.systemChannel(), dryRun, func(config *cb.Config) error {
+		return addConsortiumOrg(config, name, peerOrgs[0].Name, group)
+	})
+}
+
+// DeleteConsortiumOrg removes delOrg from the consortium of c in the
+// system channel. No application channel is touched
+func (c *Channel) DeleteConsortiumOrg(delOrg string, dryRun bool) (*ConfigUpdatePreview, error) {
+	if delOrg == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "org should not be empty")
+	}
+	name := c.network.consortium()
+	return c.updateConfig(c.network.systemChannel(), dryRun, func(config *cb.Config) error {
+		return deleteConsortiumOrg(config, name, delOrg)
+	})
+}
+
+// addConsortiumOrg adds group as org to consortium in the system channel
+// config, creating the consortium if it doesn't exist
+func addConsortiumOrg(config *cb.Config, name string, org string, group *cb.ConfigGroup) error {
+	consortiums, err := consortiumsGroup(config)
+	if err != nil {
+		return err
+	}
+	consortium, ok := consortiums.Groups[name]
+	if !ok {
+		consortium, err = encoder.NewConsortiumGroup(&localconfig.Consortium{})
+		if err != nil {
+			return err
+		}
+		consortiums.Groups[name] = consortium
+	}
+	if _, ok := consortium.Groups[org]; ok {
+		return protocols.Errorf(protocols.CodeBadRequest, "org %s is already in consortium %s", org, name)
+	}
+	consortium.Groups[org] = group
+	return nil
+}
+
+// deleteConsortiumOrg removes org from consortium in the system channel
+// config, it stays in the other consortiums
+func deleteConsortiumOrg(config *cb.Config, name string, org string) error {
+	consortiums, err := consortiumsGroup(config)
+	if err != nil {
+		return err
+	}
+	consortium, ok := consortiums.Groups[name]
+	if !ok {
+		return protocols.Errorf(protocols.CodeNotFound, "consortium %s not found", name)
+	}
+	if _, ok := consortium.Groups[org]; !ok {
+		return protocols.Errorf(protocols.CodeNotFound, "org %s not found in consortium %s", org, name)
+	}
+	delete(consortium.Groups, org)
+	return nil
+}
+
+func consortiumsGroup(config *cb.Config) (*cb.ConfigGroup, error) {
+	consortiums, ok := config.ChannelGroup.Groups[channelconfig.ConsortiumsGroupKey]
+	if !ok {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "config has no consortiums, it isn't of the system channel")
+	}
+	return consortiums, nil
+}
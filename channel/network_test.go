@@ -0,0 +1,128 @@
+package channel
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+func TestNetworkConfig(t *testing.T) {
+	var network *NetworkConfig
+	if network.systemChannel() != sdk.DefaultSystemChainID || network.consortium() != DefaultConsortium {
+		t.Fatalf("unexpected defaults %s %s", network.systemChannel(), network.consortium())
+	}
+	network = &NetworkConfig{SystemChannel: "ordererchannel", Consortium: "consortium2"}
+	if network.systemChannel() != "ordererchannel" || network.consortium() != "consortium2" {
+		t.Fatalf("unexpected network %v", network)
+	}
+
+	config := &cb.Config{ChannelGroup: cb.NewConfigGroup()}
+	if _, err := consortiumsGroup(config); err == nil {
+		t.Error("expected error for config without consortiums")
+	}
+	config.ChannelGroup.Groups[channelconfig.ConsortiumsGroupKey] = cb.NewConfigGroup()
+	if _, err := consortiumsGroup(config); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestConsortiumOrgs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var orgs []*OrgInfo
+	for _, name := range []string{"consortiumorg1", "consortiumorg2", "consortiumorg3"} {
+		orgCA, err := sdk.NewCA(path.Join(dir, name), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orgs = append(orgs, &OrgInfo{OrgName: name, OrgMSP: name, OrgCA: orgCA})
+	}
+	orgs[0].OrdererNodes = []*ServiceNode{{ID: "orderer0.consortiumorg1", ExternalEndpoint: "127.0.0.1:7050"}}
+	block, err := GenGenesisBlock(&GenGenesisBlockRequest{
+		Orgs:        orgs[:2],
+		OrdererType: soloConsensusType,
+		Consortiums: map[string][]string{
+			"consortium1": {"consortiumorg1", "consortiumorg2"},
+			"consortium2": {"consortiumorg1"},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	original, err := configFromBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	members := func(config *cb.Config, name string) map[string]*cb.ConfigGroup {
+		consortiums, err := consortiumsGroup(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		consortium, ok := consortiums.Groups[name]
+		if !ok {
+			return nil
+		}
+		return consortium.Groups
+	}
+
+	config := proto.Clone(original).(*cb.Config)
+	group, err := sdk.NewConsortiumOrgGroup(&sdk.Organization{Name: "consortiumorg3", ID: "consortiumorg3", MSPDir: orgs[2].OrgCA.MSPDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addConsortiumOrg(config, "consortium3", "consortiumorg3", group); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := members(config, "consortium3")["consortiumorg3"]; !ok {
+		t.Fatal("expected consortium3 created with consortiumorg3")
+	}
+	if len(members(config, "consortium1")) != 2 || len(members(config, "consortium2")) != 1 {
+		t.Error("expected the other consortiums untouched")
+	}
+	if err := addConsortiumOrg(config, "consortium3", "consortiumorg3", group); err == nil {
+		t.Error("expected error adding an org twice")
+	}
+
+	config = proto.Clone(original).(*cb.Config)
+	if err := deleteConsortiumOrg(config, "consortium1", "consortiumorg1"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := members(config, "consortium1")["consortiumorg1"]; ok {
+		t.Error("expected consortiumorg1 removed from consortium1")
+	}
+	if _, ok := members(config, "consortium2")["consortiumorg1"]; !ok {
+		t.Error("expected consortiumorg1 kept in consortium2")
+	}
+	if err := deleteConsortiumOrg(config, "consortium1", "consortiumorg1"); err == nil {
+		t.Error("expected error removing a missing org")
+	}
+	if err := deleteConsortiumOrg(config, "consortium4", "consortiumorg1"); err == nil {
+		t.Error("expected error for a missing consortium")
+	}
+
+	// DeleteOrg removes the org from the consortium of the request only
+	client := &sdk.Client{}
+	old, updated, err := client.GetDelOrgChannelConfig("systemchain", block, "consortiumorg1", nil, "consortium2")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !proto.Equal(old, original) {
+		t.Error("expected the original config untouched")
+	}
+	if _, ok := members(updated, "consortium2")["consortiumorg1"]; ok {
+		t.Error("expected consortiumorg1 removed from consortium2")
+	}
+	if _, ok := members(updated, "consortium1")["consortiumorg1"]; !ok {
+		t.Error("expected consortiumorg1 kept in consortium1")
+	}
+}
@@ -0,0 +1,109 @@
+package channel
+
+import (
+	"manageChain/protocols"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+)
+
+// UpdateOrdererConfig changes how the orderers of channelName cut blocks
+// and, for kafka, which brokers they use. Zero fields of batch and empty
+// kafkaBrokers are left as they are
+func (c *Channel) UpdateOrdererConfig(channelName string, batch *BatchConfig, kafkaBrokers []string, dryRun bool) (*ConfigUpdatePreview, error) {
+	if batch == nil {
+		batch = &BatchConfig{}
+	}
+	timeout, err := batch.timeout()
+	if err != nil {
+		return nil, err
+	}
+	return c.updateConfig(channelName, dryRun, func(config *cb.Config) error {
+		orderer, ok := config.ChannelGroup.Groups[channelconfig.OrdererGroupKey]
+		if !ok {
+			return protocols.Errorf(protocols.CodeBadRequest, "channel %s has no orderer config", channelName)
+		}
+
+		batchSize := &ab.BatchSize{}
+		if err := unmarshalConfigValue(orderer, channelconfig.BatchSizeKey, batchSize); err != nil {
+			return err
+		}
+		if batch.MaxMessageCount != 0 {
+			batchSize.MaxMessageCount = batch.MaxMessageCount
+		}
+		if batch.AbsoluteMaxBytes != 0 {
+			batchSize.AbsoluteMaxBytes = batch.AbsoluteMaxBytes
+		}
+		if batch.PreferredMaxBytes != 0 {
+			batchSize.PreferredMaxBytes = batch.PreferredMaxBytes
+		}
+		if batchSize.PreferredMaxBytes > batchSize.AbsoluteMaxBytes {
+			return protocols.Errorf(protocols.CodeBadRequest, "preferred max bytes %d should not exceed absolute max bytes %d", batchSize.PreferredMaxBytes, batchSize.AbsoluteMaxBytes)
+		}
+		if err := marshalConfigValue(orderer, channelconfig.BatchSizeKey, batchSize); err != nil {
+			return err
+		}
+
+		if timeout != 0 {
+			if err := marshalConfigValue(orderer, channelconfig.BatchTimeoutKey, &ab.BatchTimeout{Timeout: timeout.String()}); err != nil {
+				return err
+			}
+		}
+
+		if len(kafkaBrokers) != 0 {
+			consensus := &ab.ConsensusType{}
+			if err := unmarshalConfigValue(orderer, channelconfig.ConsensusTypeKey, consensus); err != nil {
+				return err
+			}
+			if consensus.Type != kafkaConsensusType {
+				return protocols.Errorf(protocols.CodeBadRequest, "orderers of %s are %s, not kafka", channelName, consensus.Type)
+			}
+			if err := marshalConfigValue(orderer, channelconfig.KafkaBrokersKey, &ab.KafkaBrokers{Brokers: kafkaBrokers}); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// timeout parses BatchTimeout, 0 if empty
+func (b *BatchConfig) timeout() (time.Duration, error) {
+	if b.BatchTimeout == "" {
+		return 0, nil
+	}
+	timeout, err := time.ParseDuration(b.BatchTimeout)
+	if err != nil {
+		return 0, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	if timeout <= 0 {
+		return 0, protocols.Errorf(protocols.CodeBadRequest, "batch timeout %s should be positive", b.BatchTimeout)
+	}
+	return timeout, nil
+}
+
+func unmarshalConfigValue(group *cb.ConfigGroup, key string, msg proto.Message) error {
+	value, ok := group.Values[key]
+	if !ok {
+		return protocols.Errorf(protocols.CodeBadRequest, "config value %s not found", key)
+	}
+	return proto.Unmarshal(value.Value, msg)
+}
+
+// marshalConfigValue sets the value of key in group, keeping the mod
+// policy of the former value
+func marshalConfigValue(group *cb.ConfigGroup, key string, msg proto.Message) error {
+	data, err := proto.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	value, ok := group.Values[key]
+	if !ok {
+		value = &cb.ConfigValue{ModPolicy: channelconfig.AdminsPolicyKey}
+		group.Values[key] = value
+	}
+	value.Value = data
+	return nil
+}
@@ -0,0 +1,535 @@
+package channel
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"manageChain/protocols"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/capabilities"
+	"github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/common/util"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+const defaultPendingUpdateDir = "pendingupdates"
+
+// ProposeConfigUpdate stores a config update of req.ChannelName, the
+// members then add their signatures by SignPendingUpdate, or by
+// AddPendingSignature when another manageChain instance holds their admin
+// msp
+func (c *Channel) ProposeConfigUpdate(req *PendingUpdateRequest) (*PendingUpdateStatus, error) {
+	if req.ChannelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+
+	update := req.ConfigUpdate
+	var err error
+	c.step("compute config update")
+	switch {
+	case len(update) != 0:
+	case len(req.Identity) != 0:
+		update, err = c.addOrgConfigUpdate(req.ChannelName, req.Identity)
+	case req.DelOrg != "":
+		casters := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, c.orgs[0].OrgCA.TLSCACert())
+		update, err = c.createDelOrgChannelConfigUpdate(req.ChannelName, req.DelOrg, req.DelOrderers, casters)
+	default:
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "one of config update, identity or deleted org should be given")
+	}
+	if err != nil {
+		logger.Error("Error computing config update", err)
+		return nil, err
+	}
+	return c.proposeConfigUpdate(req.ChannelName, update, nil)
+}
+
+// proposeConfigUpdate stores update as a new pending update of
+// channelName, init fills in what the caller links it to
+func (c *Channel) proposeConfigUpdate(channelName string, update []byte, init func(*PendingUpdate)) (*PendingUpdateStatus, error) {
+	configUpdate := &cb.ConfigUpdate{}
+	if err := proto.Unmarshal(update, configUpdate); err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	if configUpdate.ChannelId != channelName {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "config update is for channel %s, not %s", configUpdate.ChannelId, channelName)
+	}
+
+	config, err := c.channelConfig(channelName)
+	if err != nil {
+		logger.Error("Error getting channel config", err)
+		return nil, err
+	}
+	id, err := newPendingID()
+	if err != nil {
+		return nil, err
+	}
+	rawConfig, err := proto.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	pending := &PendingUpdate{
+		ID:           id,
+		ChannelName:  channelName,
+		ConfigUpdate: update,
+		Config:       rawConfig,
+		State:        PendingUpdateCollecting,
+		CreateTime:   now,
+		UpdateTime:   now,
+	}
+	if init != nil {
+		init(pending)
+	}
+	if err := pendingUpdates().add(pending); err != nil {
+		logger.Error("Error saving pending update", err)
+		return nil, err
+	}
+	return pendingStatus(pending, config, c.gm)
+}
+
+// SignPendingUpdate adds the ConfigSignature of the admin of the first org
+// to a pending update, replacing the former one of its msp
+func (c *Channel) SignPendingUpdate(id string) (*PendingUpdateStatus, error) {
+	pending, err := pendingUpdates().get(id)
+	if err != nil {
+		return nil, err
+	}
+	if pending.State != PendingUpdateCollecting {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "pending update %s is already %s", id, pending.State)
+	}
+
+	c.step("sign config update")
+	sigHeader, signature, err := c.orgs[0].Client.SignChannelConfigUpdate(pending.ConfigUpdate)
+	if err != nil {
+		logger.Error("Error signing config update", err)
+		return nil, err
+	}
+	config, err := c.channelConfig(pending.ChannelName)
+	if err != nil {
+		logger.Error("Error getting channel config", err)
+		return nil, err
+	}
+	return addPendingSignature(id, config, sigHeader, signature, c.gm)
+}
+
+// AddPendingSignature adds a ConfigSignature made elsewhere, by another
+// manageChain instance or an offline signer, to a pending update. No local
+// org is needed, the signature is verified against the config the update
+// was proposed on
+func AddPendingSignature(id string, sigHeader []byte, signature []byte, gm bool) (*PendingUpdateStatus, error) {
+	if len(sigHeader) == 0 || len(signature) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "signature header and signature should not be empty")
+	}
+	pending, err := pendingUpdates().get(id)
+	if err != nil {
+		return nil, err
+	}
+	if pending.State != PendingUpdateCollecting {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "pending update %s is already %s", id, pending.State)
+	}
+	if len(pending.Config) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "pending update %s keeps no config, sign it as a local org", id)
+	}
+	config := &cb.Config{}
+	if err := proto.Unmarshal(pending.Config, config); err != nil {
+		return nil, err
+	}
+	return addPendingSignature(id, config, sigHeader, signature, gm)
+}
+
+// SignConfigUpdate signs a marshaled common.ConfigUpdate as the admin of
+// the first org, for a pending update kept by another manageChain instance
+func (c *Channel) SignConfigUpdate(update []byte) (*ConfigSignature, error) {
+	if err := proto.Unmarshal(update, &cb.ConfigUpdate{}); err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	sigHeader, signature, err := c.orgs[0].Client.SignChannelConfigUpdate(update)
+	if err != nil {
+		logger.Error("Error signing config update", err)
+		return nil, err
+	}
+	return &ConfigSignature{SignatureHeader: sigHeader, Signature: signature}, nil
+}
+
+// addPendingSignature verifies signature against config and puts it in
+// place of the former one of its msp
+func addPendingSignature(id string, config *cb.Config, sigHeader []byte, signature []byte, gm bool) (*PendingUpdateStatus, error) {
+	pending, err := pendingUpdates().get(id)
+	if err != nil {
+		return nil, err
+	}
+	mspID, err := verifyConfigSignature(config, pending.ConfigUpdate, sigHeader, signature, gm)
+	if err != nil {
+		return nil, err
+	}
+
+	sig := &PendingSignature{
+		MspID:           mspID,
+		SignatureHeader: sigHeader,
+		Signature:       signature,
+		SignTime:        time.Now(),
+	}
+	pending, err = pendingUpdates().update(id, func(u *PendingUpdate) error {
+		if u.State != PendingUpdateCollecting {
+			return protocols.Errorf(protocols.CodeBadRequest, "pending update %s is already %s", id, u.State)
+		}
+		sigs := []*PendingSignature{sig}
+		for _, s := range u.Signatures {
+			if s.MspID != mspID {
+				sigs = append(sigs, s)
+			}
+		}
+		sort.Slice(sigs, func(i, j int) bool { return sigs[i].SignTime.Before(sigs[j].SignTime) })
+		u.Signatures = sigs
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return pendingStatus(pending, config, gm)
+}
+
+// PendingUpdateStatus evaluates the signatures of a pending update against
+// the policies it requires
+func (c *Channel) PendingUpdateStatus(id string) (*PendingUpdateStatus, error) {
+	pending, err := pendingUpdates().get(id)
+	if err != nil {
+		return nil, err
+	}
+	config, err := c.channelConfig(pending.ChannelName)
+	if err != nil {
+		logger.Error("Error getting channel config", err)
+		return nil, err
+	}
+	return pendingStatus(pending, config, c.gm)
+}
+
+// SubmitPendingUpdate broadcasts a pending update through the first org
+// once enough members signed it
+func (c *Channel) SubmitPendingUpdate(id string) (*PendingUpdateStatus, error) {
+	status, err := c.PendingUpdateStatus(id)
+	if err != nil {
+		return nil, err
+	}
+	if status.State != PendingUpdateCollecting {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "pending update %s is already %s", id, status.State)
+	}
+	if !status.Ready {
+		var unsatisfied []string
+		for _, policy := range status.Policies {
+			if !policy.Satisfied {
+				unsatisfied = append(unsatisfied, policy.Path)
+			}
+		}
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "pending update %s doesn't satisfy %s yet", id, strings.Join(unsatisfied, ", "))
+	}
+
+	signed := make(map[string]bool)
+	for _, mspID := range status.SignedBy {
+		signed[mspID] = true
+	}
+	sigs := []*cb.ConfigSignature{}
+	for _, sig := range status.Signatures {
+		if signed[sig.MspID] {
+			sigs = append(sigs, &cb.ConfigSignature{
+				SignatureHeader: sig.SignatureHeader,
+				Signature:       sig.Signature,
+			})
+		}
+	}
+
+	c.step("broadcast config update")
+	if err := c.broadcastConfigUpdate(status.ChannelName, status.ConfigUpdate, sigs); err != nil {
+		return nil, err
+	}
+	pending, err := pendingUpdates().update(id, func(u *PendingUpdate) error {
+		u.State = PendingUpdateSubmitted
+		return nil
+	})
+	if err != nil {
+		logger.Error("Error saving pending update", err)
+		return nil, err
+	}
+	status.PendingUpdate = pending
+	logger.Info("Successfully submit pending update %s", id)
+	return status, nil
+}
+
+// PendingUpdates lists the pending updates of channelName, oldest first
+func PendingUpdates(channelName string) []*PendingUpdate {
+	return pendingUpdates().list(channelName)
+}
+
+// addOrgConfigUpdate computes the update adding the org of identity into
+// channelName, like AddOrg does for the channel itself
+func (c *Channel) addOrgConfigUpdate(channelName string, identity []byte) ([]byte, error) {
+	ic, peerOrgs, ordererOrgs, err := identityOrgs(identity)
+	if err != nil {
+		return nil, err
+	}
+	casters := serviceNodesToEndpointList(c.orgs[0].OrdererNodes, CreateChannelTimeout, c.orgs[0].OrgCA.TLSCACert())
+	return c.createAddOrgChannelConfigUpdate(channelName, peerOrgs, ordererOrgs, nil, ic.Orderers, casters)
+}
+
+// verifyConfigSignature returns the msp of the signer once signature is
+// checked to be made over sigHeader and update by an org of config, the
+// orderer orgs and the consortium ones included
+func verifyConfigSignature(config *cb.Config, update []byte, sigHeader []byte, signature []byte, gm bool) (string, error) {
+	header, err := utils.GetSignatureHeader(sigHeader)
+	if err != nil {
+		return "", protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	msps, err := channelMSPs(config)
+	if err != nil {
+		return "", err
+	}
+	mspID := creatorMSP(header.Creator)
+	mspConfig, ok := msps[mspID]
+	if !ok {
+		return "", protocols.Errorf(protocols.CodeForbidden, "signer %s is not a member of the channel", mspID)
+	}
+	if err := sdk.VerifySignature(mspConfig, header.Creator, util.ConcatenateBytes(sigHeader, update), signature, gm); err != nil {
+		return "", protocols.Errorf(protocols.CodeForbidden, "bad signature of %s: %s", mspID, err)
+	}
+	return mspID, nil
+}
+
+// pendingStatus evaluates the signatures of the msps still in config
+// against the mod policies of every element the update writes, as the
+// orderers would do
+func pendingStatus(pending *PendingUpdate, config *cb.Config, gm bool) (*PendingUpdateStatus, error) {
+	configUpdate := &cb.ConfigUpdate{}
+	if err := proto.Unmarshal(pending.ConfigUpdate, configUpdate); err != nil {
+		return nil, err
+	}
+	manager, err := policyManager(config, gm)
+	if err != nil {
+		logger.Error("Error creating channel policies", err)
+		return nil, err
+	}
+	msps, err := channelMSPs(config)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &PendingUpdateStatus{PendingUpdate: pending}
+	signedData := []*cb.SignedData{}
+	for _, sig := range pending.Signatures {
+		if _, ok := msps[sig.MspID]; !ok {
+			continue
+		}
+		header, err := utils.GetSignatureHeader(sig.SignatureHeader)
+		if err != nil {
+			return nil, err
+		}
+		status.SignedBy = append(status.SignedBy, sig.MspID)
+		signedData = append(signedData, &cb.SignedData{
+			Data:      util.ConcatenateBytes(sig.SignatureHeader, pending.ConfigUpdate),
+			Identity:  header.Creator,
+			Signature: sig.Signature,
+		})
+	}
+
+	for _, requirement := range modPolicies(config, configUpdate) {
+		policy, ok := manager.GetPolicy(requirement.Path)
+		status.Policies = append(status.Policies, &PolicyStatus{
+			PolicyRequirement: requirement,
+			Satisfied:         ok && policy.Evaluate(signedData) == nil,
+		})
+	}
+	status.Ready = len(status.Policies) != 0
+	for _, policy := range status.Policies {
+		status.Ready = status.Ready && policy.Satisfied
+	}
+	return status, nil
+}
+
+// policyManager returns the policies of config, they check the signatures
+// by msps on their own bccsp, GM if gm
+func policyManager(config *cb.Config, gm bool) (policies.Manager, error) {
+	caps := &cb.Capabilities{}
+	if _, ok := config.ChannelGroup.Values[channelconfig.CapabilitiesKey]; ok {
+		if err := unmarshalConfigValue(config.ChannelGroup, channelconfig.CapabilitiesKey, caps); err != nil {
+			return nil, err
+		}
+	}
+	msps, err := channelMSPs(config)
+	if err != nil {
+		return nil, err
+	}
+	mspConfigs := []*mspprotos.MSPConfig{}
+	for _, mspConfig := range msps {
+		mspConfigs = append(mspConfigs, mspConfig)
+	}
+	manager, err := sdk.NewMSPManager(mspConfigs, capabilities.NewChannelProvider(caps.Capabilities).MSPVersion(), gm)
+	if err != nil {
+		return nil, err
+	}
+	return policies.NewManagerImpl(channelconfig.RootGroupKey, map[int32]policies.Provider{
+		int32(cb.Policy_SIGNATURE): cauthdsl.NewPolicyProvider(manager),
+	}, config.ChannelGroup)
+}
+
+// pendingStore keeps the pending updates in dir, one json file per update
+type pendingStore struct {
+	dir     string
+	lock    sync.Mutex
+	updates map[string]*PendingUpdate
+}
+
+var (
+	defaultPendingStore *pendingStore
+	pendingOnce         sync.Once
+)
+
+// pendingUpdates returns the store of the PendingUpdateDir of app.conf
+func pendingUpdates() *pendingStore {
+	pendingOnce.Do(func() {
+		dir := beego.AppConfig.DefaultString("PendingUpdateDir", defaultPendingUpdateDir)
+		var err error
+		defaultPendingStore, err = loadPendingStore(dir)
+		if err != nil {
+			logger.Error("Error loading pending updates from %s: %s", dir, err)
+			defaultPendingStore = &pendingStore{dir: dir, updates: make(map[string]*PendingUpdate)}
+		}
+	})
+	return defaultPendingStore
+}
+
+func loadPendingStore(dir string) (*pendingStore, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	s := &pendingStore{
+		dir:     dir,
+		updates: make(map[string]*PendingUpdate),
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		pending := &PendingUpdate{}
+		if err := json.Unmarshal(data, pending); err != nil {
+			logger.Error("Error unmarshaling pending update %s: %s", file.Name(), err)
+			continue
+		}
+		s.updates[pending.ID] = pending
+	}
+	return s, nil
+}
+
+func (s *pendingStore) add(pending *PendingUpdate) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if err := s.save(pending); err != nil {
+		return err
+	}
+	s.updates[pending.ID] = pending
+	return nil
+}
+
+// get returns a copy of the pending update id
+func (s *pendingStore) get(id string) (*PendingUpdate, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pending, ok := s.updates[id]
+	if !ok {
+		return nil, protocols.Errorf(protocols.CodeNotFound, "pending update %s not found", id)
+	}
+	return pending.clone(), nil
+}
+
+// update applies fn to a copy of the pending update id and persists it,
+// nothing changes if fn fails
+func (s *pendingStore) update(id string, fn func(*PendingUpdate) error) (*PendingUpdate, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	pending, ok := s.updates[id]
+	if !ok {
+		return nil, protocols.Errorf(protocols.CodeNotFound, "pending update %s not found", id)
+	}
+	pending = pending.clone()
+	if err := fn(pending); err != nil {
+		return nil, err
+	}
+	pending.UpdateTime = time.Now()
+	if err := s.save(pending); err != nil {
+		return nil, err
+	}
+	s.updates[id] = pending
+	return pending.clone(), nil
+}
+
+func (s *pendingStore) list(channelName string) []*PendingUpdate {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	updates := []*PendingUpdate{}
+	for _, pending := range s.updates {
+		if pending.ChannelName == channelName {
+			updates = append(updates, pending.clone())
+		}
+	}
+	sort.Slice(updates, func(i, j int) bool { return updates[i].CreateTime.Before(updates[j].CreateTime) })
+	return updates
+}
+
+// invitation returns a copy of the pending update adding the invitee of an
+// invitation, nil if none was proposed
+func (s *pendingStore) invitation(channelName string, inviter string, invitee string) *PendingUpdate {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, pending := range s.updates {
+		if pending.ChannelName == channelName && pending.Inviter == inviter && pending.Invitee == invitee {
+			return pending.clone()
+		}
+	}
+	return nil
+}
+
+func (s *pendingStore) save(pending *PendingUpdate) error {
+	data, err := json.Marshal(pending)
+	if err != nil {
+		return err
+	}
+	tmp := path.Join(s.dir, pending.ID+".json.tmp")
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path.Join(s.dir, pending.ID+".json"))
+}
+
+func (u *PendingUpdate) clone() *PendingUpdate {
+	c := *u
+	c.Signatures = append([]*PendingSignature(nil), u.Signatures...)
+	return &c
+}
+
+func newPendingID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,161 @@
+package channel
+
+import (
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/tools/configtxlator/update"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+func TestPendingStatus(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	orgs := make(map[string]*sdk.Organization)
+	clients := make(map[string]*sdk.Client)
+	for _, name := range []string{"ordererorg", "consortiumorg1", "consortiumorg2"} {
+		orgCA, err := sdk.NewCA(path.Join(dir, name), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orgs[name] = &sdk.Organization{Name: name, ID: name, MSPDir: orgCA.MSPDir()}
+		if clients[name], err = sdk.NewClient(orgCA.AdminCommonName(), name, orgCA.AdminMSPDir(), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block := sdk.CreateGenesisBlock(&sdk.GenesisConfig{
+		ChainID:                 "systemchain",
+		OrdererType:             sdk.OrdererTypeSolo,
+		Addresses:               []string{"orderer0:7050"},
+		OrdererOrganizations:    []*sdk.Organization{orgs["ordererorg"]},
+		ConsortiumOrganizations: []*sdk.Organization{orgs["consortiumorg1"]},
+	})
+	config, err := configFromBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// adding consortiumorg2 into the consortium is governed by the orderer admins
+	updated := proto.Clone(config).(*cb.Config)
+	consortium := updated.ChannelGroup.Groups[channelconfig.ConsortiumsGroupKey].Groups[sdk.DefaultConsortium]
+	if consortium.Groups["consortiumorg2"], err = sdk.NewConsortiumOrgGroup(orgs["consortiumorg2"]); err != nil {
+		t.Fatal(err)
+	}
+	configUpdate, err := update.Compute(config, updated)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configUpdate.ChannelId = "systemchain"
+	pending := &PendingUpdate{ChannelName: "systemchain", ConfigUpdate: utils.MarshalOrPanic(configUpdate)}
+
+	sign := func(org string) {
+		sigHeader, signature, err := clients[org].SignChannelConfigUpdate(pending.ConfigUpdate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		mspID, err := verifyConfigSignature(config, pending.ConfigUpdate, sigHeader, signature, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		pending.Signatures = append(pending.Signatures, &PendingSignature{MspID: mspID, SignatureHeader: sigHeader, Signature: signature})
+	}
+
+	sign("consortiumorg1")
+	status, err := pendingStatus(pending, config, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Ready || len(status.Policies) != 1 || status.Policies[0].Path != "/Channel/Orderer/Admins" {
+		t.Fatalf("unexpected status %v %v", status.Policies, status.Ready)
+	}
+
+	sign("ordererorg")
+	if status, err = pendingStatus(pending, config, false); err != nil {
+		t.Fatal(err)
+	}
+	if !status.Ready || len(status.SignedBy) != 2 {
+		t.Fatalf("expected update ready once signed by the orderer org, got %v", status.SignedBy)
+	}
+}
+
+func TestAddPendingSignature(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	pendingOnce.Do(func() {
+		defaultPendingStore = &pendingStore{dir: dir, updates: make(map[string]*PendingUpdate)}
+	})
+
+	orgs := make(map[string]*sdk.Organization)
+	clients := make(map[string]*sdk.Client)
+	for _, name := range []string{"remoteorderer", "remoteorg", "remoteoutsider"} {
+		orgCA, err := sdk.NewCA(path.Join(dir, name), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		orgs[name] = &sdk.Organization{Name: name, ID: name, MSPDir: orgCA.MSPDir()}
+		if clients[name], err = sdk.NewClient(orgCA.AdminCommonName(), name, orgCA.AdminMSPDir(), false); err != nil {
+			t.Fatal(err)
+		}
+	}
+	block := sdk.CreateGenesisBlock(&sdk.GenesisConfig{
+		ChainID:                 "remotechain",
+		OrdererType:             sdk.OrdererTypeSolo,
+		Addresses:               []string{"orderer0:7050"},
+		OrdererOrganizations:    []*sdk.Organization{orgs["remoteorderer"]},
+		ConsortiumOrganizations: []*sdk.Organization{orgs["remoteorg"]},
+	})
+	config, err := configFromBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configUpdate := utils.MarshalOrPanic(&cb.ConfigUpdate{ChannelId: "remotechain"})
+	for _, pending := range []*PendingUpdate{
+		{ID: "withconfig", ChannelName: "remotechain", ConfigUpdate: configUpdate, Config: utils.MarshalOrPanic(config), State: PendingUpdateCollecting},
+		{ID: "withoutconfig", ChannelName: "remotechain", ConfigUpdate: configUpdate, State: PendingUpdateCollecting},
+	} {
+		if err := pendingUpdates().add(pending); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// the signatures are made by another instance and posted as they are
+	sign := func(org string) ([]byte, []byte) {
+		sigHeader, signature, err := clients[org].SignChannelConfigUpdate(configUpdate)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return sigHeader, signature
+	}
+
+	sigHeader, signature := sign("remoteorderer")
+	status, err := AddPendingSignature("withconfig", sigHeader, signature, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(status.SignedBy) != 1 || status.SignedBy[0] != "remoteorderer" {
+		t.Fatalf("expected the signature of remoteorderer, got %v", status.SignedBy)
+	}
+	if _, err := AddPendingSignature("withoutconfig", sigHeader, signature, false); err == nil {
+		t.Error("expected error for a pending update without config")
+	}
+	if _, err := AddPendingSignature("withconfig", sigHeader, signature[1:], false); err == nil {
+		t.Error("expected error for a bad signature")
+	}
+	sigHeader, signature = sign("remoteoutsider")
+	if _, err := AddPendingSignature("withconfig", sigHeader, signature, false); err == nil {
+		t.Error("expected error for the signature of an outsider")
+	}
+}
@@ -0,0 +1,161 @@
+package channel
+
+import (
+	"manageChain/protocols"
+
+	"github.com/hyperledger/fabric/common/cauthdsl"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/policies"
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/protos/utils"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// policy types of PolicyConfig
+const (
+	PolicyTypeImplicitMeta = sdk.PolicyTypeImplicitMeta
+	PolicyTypeSignature    = sdk.PolicyTypeSignature
+)
+
+// policyNames are the policies a channel or an org may define
+var policyNames = []string{
+	channelconfig.AdminsPolicyKey,
+	channelconfig.WritersPolicyKey,
+	channelconfig.ReadersPolicyKey,
+}
+
+// UpdatePolicies replaces policies of channelName, the ones of the
+// application group, or of the application org whose msp is org if given
+func (c *Channel) UpdatePolicies(channelName string, org string, policies map[string]*PolicyConfig, dryRun bool) (*ConfigUpdatePreview, error) {
+	if len(policies) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "policies should not be empty")
+	}
+	if org != "" {
+		if err := checkOrgPolicies(policies); err != nil {
+			return nil, err
+		}
+	}
+	values, err := policyValues(policies)
+	if err != nil {
+		return nil, err
+	}
+	return c.updateConfig(channelName, dryRun, func(config *cb.Config) error {
+		group, ok := config.ChannelGroup.Groups[channelconfig.ApplicationGroupKey]
+		if !ok {
+			return protocols.Errorf(protocols.CodeBadRequest, "channel %s has no application orgs", channelName)
+		}
+		if org != "" {
+			var err error
+			if group, err = applicationOrg(config, org); err != nil {
+				return err
+			}
+		}
+		for name, value := range values {
+			policy, ok := group.Policies[name]
+			if !ok {
+				policy = &cb.ConfigPolicy{ModPolicy: channelconfig.AdminsPolicyKey}
+				group.Policies[name] = policy
+			}
+			policy.Policy = value
+		}
+		return nil
+	})
+}
+
+// policyValues checks the names and compiles the rules of policies
+func policyValues(policies map[string]*PolicyConfig) (map[string]*cb.Policy, error) {
+	values := make(map[string]*cb.Policy)
+	for name, policy := range policies {
+		if !isPolicyName(name) {
+			return nil, protocols.Errorf(protocols.CodeBadRequest, "unknown policy %s, should be one of %v", name, policyNames)
+		}
+		if policy == nil {
+			return nil, protocols.Errorf(protocols.CodeBadRequest, "policy %s should not be empty", name)
+		}
+		value, err := policy.value()
+		if err != nil {
+			return nil, err
+		}
+		values[name] = value
+	}
+	return values, nil
+}
+
+func (p *PolicyConfig) value() (*cb.Policy, error) {
+	switch p.Type {
+	case PolicyTypeImplicitMeta:
+		imp, err := policies.ImplicitMetaFromString(p.Rule)
+		if err != nil {
+			return nil, protocols.Errorf(protocols.CodeBadRequest, "invalid implicit meta policy %s: %s", p.Rule, err)
+		}
+		return &cb.Policy{Type: int32(cb.Policy_IMPLICIT_META), Value: utils.MarshalOrPanic(imp)}, nil
+	case PolicyTypeSignature:
+		sp, err := cauthdsl.FromString(p.Rule)
+		if err != nil {
+			return nil, protocols.Errorf(protocols.CodeBadRequest, "invalid signature policy %s: %s", p.Rule, err)
+		}
+		return &cb.Policy{Type: int32(cb.Policy_SIGNATURE), Value: utils.MarshalOrPanic(sp)}, nil
+	}
+	return nil, protocols.Errorf(protocols.CodeBadRequest, "policy type should be %s or %s, not %s", PolicyTypeImplicitMeta, PolicyTypeSignature, p.Type)
+}
+
+// sdkPolicies checks policies and converts them for the sdk, nil if empty
+func sdkPolicies(policies map[string]*PolicyConfig) (map[string]*sdk.Policy, error) {
+	if len(policies) == 0 {
+		return nil, nil
+	}
+	if _, err := policyValues(policies); err != nil {
+		return nil, err
+	}
+	ret := make(map[string]*sdk.Policy)
+	for name, policy := range policies {
+		ret[name] = &sdk.Policy{
+			Type: policy.Type,
+			Rule: policy.Rule,
+		}
+	}
+	return ret, nil
+}
+
+// orgPolicies are the policies of the org of mspID, the ones it doesn't
+// define are the admin and member signature policies of its msp
+func orgPolicies(mspID string, policies map[string]*PolicyConfig) (map[string]*sdk.Policy, error) {
+	if err := checkOrgPolicies(policies); err != nil {
+		return nil, err
+	}
+	ret, err := sdkPolicies(policies)
+	if ret == nil || err != nil {
+		return ret, err
+	}
+	defaults := map[string]string{
+		channelconfig.AdminsPolicyKey:  "OR('" + mspID + ".admin')",
+		channelconfig.WritersPolicyKey: "OR('" + mspID + ".member')",
+		channelconfig.ReadersPolicyKey: "OR('" + mspID + ".member')",
+	}
+	for name, rule := range defaults {
+		if _, ok := ret[name]; !ok {
+			ret[name] = &sdk.Policy{Type: PolicyTypeSignature, Rule: rule}
+		}
+	}
+	return ret, nil
+}
+
+// checkOrgPolicies only accepts signature policies, an org has no sub
+// groups so an implicit meta policy of it requires no signature at all
+func checkOrgPolicies(policies map[string]*PolicyConfig) error {
+	for name, policy := range policies {
+		if policy != nil && policy.Type != PolicyTypeSignature {
+			return protocols.Errorf(protocols.CodeBadRequest, "policy %s of an org should be of type %s, not %s", name, PolicyTypeSignature, policy.Type)
+		}
+	}
+	return nil
+}
+
+func isPolicyName(name string) bool {
+	for _, n := range policyNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
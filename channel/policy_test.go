@@ -0,0 +1,57 @@
+package channel
+
+import (
+	"testing"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+func TestPolicyValues(t *testing.T) {
+	values, err := policyValues(map[string]*PolicyConfig{
+		"Admins":  {Type: PolicyTypeSignature, Rule: "AND('Org1MSP.admin','Org2MSP.admin')"},
+		"Writers": {Type: PolicyTypeImplicitMeta, Rule: "ANY Writers"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if values["Admins"].Type != int32(cb.Policy_SIGNATURE) || values["Writers"].Type != int32(cb.Policy_IMPLICIT_META) {
+		t.Fatalf("unexpected policies %v", values)
+	}
+
+	for _, policies := range []map[string]*PolicyConfig{
+		{"Endorsement": {Type: PolicyTypeImplicitMeta, Rule: "ANY Writers"}},
+		{"Admins": nil},
+		{"Admins": {Type: "Unknown", Rule: "ANY Admins"}},
+		{"Admins": {Type: PolicyTypeImplicitMeta, Rule: "SOME Admins"}},
+		{"Admins": {Type: PolicyTypeSignature, Rule: "AND(Org1MSP.admin"}},
+	} {
+		if _, err := policyValues(policies); err == nil {
+			t.Errorf("expected error compiling %v", policies)
+		}
+	}
+
+	orgs, err := orgPolicies("Org1MSP", map[string]*PolicyConfig{
+		"Admins": {Type: PolicyTypeSignature, Rule: "OR('Org1MSP.peer')"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(orgs) != 3 || orgs["Admins"].Rule != "OR('Org1MSP.peer')" || orgs["Readers"].Rule != "OR('Org1MSP.member')" {
+		t.Fatalf("unexpected org policies %v", orgs)
+	}
+
+	for _, policies := range []map[string]*PolicyConfig{
+		{"Admins": {Type: PolicyTypeImplicitMeta, Rule: "ANY Admins"}},
+		{"Writers": {Type: PolicyTypeImplicitMeta, Rule: "MAJORITY Writers"}},
+	} {
+		if _, err := orgPolicies("Org1MSP", policies); err == nil {
+			t.Errorf("expected error for org policies %v", policies)
+		}
+	}
+
+	if _, err := (&Channel{}).UpdatePolicies("mychannel", "Org1MSP", map[string]*PolicyConfig{
+		"Admins": {Type: PolicyTypeImplicitMeta, Rule: "ANY Admins"},
+	}, true); err == nil {
+		t.Error("expected error updating an org policy to implicit meta")
+	}
+}
@@ -0,0 +1,324 @@
+package channel
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+)
+
+// The protobuf of this tree comes without jsonpb, so messages are encoded
+// here the way configtxlator does it: jsonpb with the original field names
+// and the defaults emitted, 64 bits integers as strings, enums by name,
+// bytes as base64 and oneofs flattened. The bytes fields which carry a
+// known message, see nestedMessage, are decoded in place.
+
+// marshalProtoJSON encodes msg as configtxlator does
+func marshalProtoJSON(msg proto.Message) (json.RawMessage, error) {
+	tree, err := protoTree(msg)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(tree)
+}
+
+// unmarshalProtoJSON decodes data encoded by marshalProtoJSON or
+// configtxlator into msg
+func unmarshalProtoJSON(data json.RawMessage, msg proto.Message) error {
+	return decodeProtoMessage(data, reflect.ValueOf(msg).Elem())
+}
+
+// nestedMessage returns the message carried by the bytes field of msg,
+// nil if it carries none
+func nestedMessage(msg proto.Message, field string) proto.Message {
+	switch m := msg.(type) {
+	case *cb.Policy:
+		if field == "value" {
+			return policyMessage(m.Type)
+		}
+	case *mspprotos.MSPConfig:
+		if field == "config" && m.Type == 0 {
+			return &mspprotos.FabricMSPConfig{}
+		}
+	case *mspprotos.MSPPrincipal:
+		if field != "principal" {
+			return nil
+		}
+		switch m.PrincipalClassification {
+		case mspprotos.MSPPrincipal_ROLE:
+			return &mspprotos.MSPRole{}
+		case mspprotos.MSPPrincipal_ORGANIZATION_UNIT:
+			return &mspprotos.OrganizationUnit{}
+		case mspprotos.MSPPrincipal_IDENTITY:
+			return &mspprotos.SerializedIdentity{}
+		}
+	}
+	return nil
+}
+
+func protoTree(msg proto.Message) (map[string]interface{}, error) {
+	v := reflect.ValueOf(msg).Elem()
+	props := proto.GetProperties(v.Type())
+	tree := make(map[string]interface{})
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if strings.HasPrefix(field.Name, "XXX_") {
+			continue
+		}
+		if field.Tag.Get("protobuf_oneof") != "" {
+			if v.Field(i).IsNil() {
+				continue
+			}
+			// the oneof holds a pointer to a struct of one field
+			wrapper := v.Field(i).Elem().Elem()
+			prop := proto.GetProperties(wrapper.Type()).Prop[0]
+			value, err := protoValue(msg, prop, wrapper.Field(0))
+			if err != nil {
+				return nil, err
+			}
+			tree[prop.OrigName] = value
+			continue
+		}
+		prop := props.Prop[i]
+		value, err := protoValue(msg, prop, v.Field(i))
+		if err != nil {
+			return nil, err
+		}
+		tree[prop.OrigName] = value
+	}
+	return tree, nil
+}
+
+func protoValue(msg proto.Message, prop *proto.Properties, v reflect.Value) (interface{}, error) {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		if nested := nestedMessage(msg, prop.OrigName); nested != nil {
+			if err := proto.Unmarshal(v.Bytes(), nested); err != nil {
+				return nil, fmt.Errorf("error decoding %s: %s", prop.OrigName, err)
+			}
+			return protoTree(nested)
+		}
+		return base64.StdEncoding.EncodeToString(v.Bytes()), nil
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		list := make([]interface{}, 0, v.Len())
+		for i := 0; i < v.Len(); i++ {
+			item, err := protoValue(msg, prop, v.Index(i))
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, item)
+		}
+		return list, nil
+	case reflect.Map:
+		m := make(map[string]interface{})
+		for _, key := range v.MapKeys() {
+			item, err := protoValue(msg, prop, v.MapIndex(key))
+			if err != nil {
+				return nil, err
+			}
+			m[fmt.Sprint(key.Interface())] = item
+		}
+		return m, nil
+	case reflect.Ptr:
+		if v.IsNil() {
+			return nil, nil
+		}
+		return protoTree(v.Interface().(proto.Message))
+	case reflect.Int32:
+		if prop.Enum != "" {
+			return fmt.Sprint(v.Interface()), nil
+		}
+		return v.Int(), nil
+	case reflect.Int64:
+		return strconv.FormatInt(v.Int(), 10), nil
+	case reflect.Uint64:
+		return strconv.FormatUint(v.Uint(), 10), nil
+	case reflect.Uint32:
+		return v.Uint(), nil
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64:
+		return v.Interface(), nil
+	}
+	return nil, fmt.Errorf("unsupported field %s of kind %s", prop.OrigName, v.Kind())
+}
+
+func decodeProtoMessage(data json.RawMessage, v reflect.Value) error {
+	fields := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return err
+	}
+	msg := v.Addr().Interface().(proto.Message)
+	props := proto.GetProperties(v.Type())
+
+	// the bytes fields carrying a message depend on the other fields,
+	// they are decoded last
+	var nested []int
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if strings.HasPrefix(field.Name, "XXX_") || field.Tag.Get("protobuf_oneof") != "" {
+			continue
+		}
+		if field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Uint8 {
+			nested = append(nested, i)
+			continue
+		}
+		prop := props.Prop[i]
+		if data, ok := lookupField(fields, prop); ok {
+			if err := decodeProtoValue(msg, prop, data, v.Field(i)); err != nil {
+				return fmt.Errorf("error decoding %s: %s", prop.OrigName, err)
+			}
+		}
+	}
+	for _, i := range nested {
+		prop := props.Prop[i]
+		if data, ok := lookupField(fields, prop); ok {
+			if err := decodeProtoValue(msg, prop, data, v.Field(i)); err != nil {
+				return fmt.Errorf("error decoding %s: %s", prop.OrigName, err)
+			}
+		}
+	}
+
+	names := make([]string, 0, len(props.OneofTypes))
+	for name := range props.OneofTypes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		oneof := props.OneofTypes[name]
+		data, ok := lookupField(fields, oneof.Prop)
+		if !ok {
+			continue
+		}
+		wrapper := reflect.New(oneof.Type.Elem())
+		if err := decodeProtoValue(msg, oneof.Prop, data, wrapper.Elem().Field(0)); err != nil {
+			return fmt.Errorf("error decoding %s: %s", name, err)
+		}
+		v.Field(oneof.Field).Set(wrapper)
+	}
+	return nil
+}
+
+func lookupField(fields map[string]json.RawMessage, prop *proto.Properties) (json.RawMessage, bool) {
+	data, ok := fields[prop.OrigName]
+	if !ok && prop.JSONName != "" {
+		data, ok = fields[prop.JSONName]
+	}
+	if !ok || string(data) == "null" {
+		return nil, false
+	}
+	return data, true
+}
+
+func decodeProtoValue(msg proto.Message, prop *proto.Properties, data json.RawMessage, v reflect.Value) error {
+	if v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.Uint8 {
+		if nested := nestedMessage(msg, prop.OrigName); nested != nil {
+			if err := unmarshalProtoJSON(data, nested); err != nil {
+				return err
+			}
+			b, err := proto.Marshal(nested)
+			if err != nil {
+				return err
+			}
+			v.SetBytes(b)
+			return nil
+		}
+		var s string
+		if err := json.Unmarshal(data, &s); err != nil {
+			return err
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return err
+		}
+		v.SetBytes(b)
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.Slice:
+		var items []json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		list := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i, item := range items {
+			if err := decodeProtoValue(msg, prop, item, list.Index(i)); err != nil {
+				return err
+			}
+		}
+		v.Set(list)
+		return nil
+	case reflect.Map:
+		var items map[string]json.RawMessage
+		if err := json.Unmarshal(data, &items); err != nil {
+			return err
+		}
+		m := reflect.MakeMap(v.Type())
+		for key, item := range items {
+			k := reflect.New(v.Type().Key()).Elem()
+			if err := decodeScalar(prop, json.RawMessage(strconv.Quote(key)), k); err != nil {
+				return err
+			}
+			value := reflect.New(v.Type().Elem()).Elem()
+			if err := decodeProtoValue(msg, prop, item, value); err != nil {
+				return err
+			}
+			m.SetMapIndex(k, value)
+		}
+		v.Set(m)
+		return nil
+	case reflect.Ptr:
+		value := reflect.New(v.Type().Elem())
+		if err := decodeProtoMessage(data, value.Elem()); err != nil {
+			return err
+		}
+		v.Set(value)
+		return nil
+	}
+	return decodeScalar(prop, data, v)
+}
+
+// decodeScalar accepts the integers either quoted or not, and the enums by
+// name or number
+func decodeScalar(prop *proto.Properties, data json.RawMessage, v reflect.Value) error {
+	switch v.Kind() {
+	case reflect.Bool, reflect.String, reflect.Float32, reflect.Float64:
+		value := reflect.New(v.Type())
+		if err := json.Unmarshal(data, value.Interface()); err != nil {
+			return err
+		}
+		v.Set(value.Elem())
+		return nil
+	}
+
+	s := strings.Trim(string(data), `"`)
+	switch v.Kind() {
+	case reflect.Int32, reflect.Int64:
+		if prop.Enum != "" {
+			if n, ok := proto.EnumValueMap(prop.Enum)[s]; ok {
+				v.SetInt(int64(n))
+				return nil
+			}
+		}
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetInt(n)
+	case reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return err
+		}
+		v.SetUint(n)
+	default:
+		return fmt.Errorf("unsupported field %s of kind %s", prop.OrigName, v.Kind())
+	}
+	return nil
+}
@@ -0,0 +1,249 @@
+package channel
+
+import (
+	"manageChain/protocols"
+	"net"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	cb "github.com/hyperledger/fabric/protos/common"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/orderer/etcdraft"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// AddConsenter adds orderer, a node of the first org of c, to the etcdraft
+// consenters and the orderer addresses of the system channel and of
+// channelNames. Its TLS cert is generated first if needed, even to preview.
+// The channels it already serves are skipped, and the previews of the
+// channels updated before an error are returned with it
+func (c *Channel) AddConsenter(orderer *ServiceNode, channelNames []string, dryRun bool) ([]*ConfigUpdatePreview, error) {
+	if orderer == nil || orderer.ID == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "orderer should not be empty")
+	}
+	orgCA := c.orgs[0].OrgCA
+	c.step("generate crypto")
+	certs := []*sdk.CertConfig{&sdk.CertConfig{
+		CN:       orderer.ID,
+		SAN:      filterSAN([]string{splitIP(orderer.ExternalEndpoint)}),
+		NodeType: sdk.OrdererNode,
+	}}
+	if err := orgCA.GenerateMSP(certs, nil); err != nil {
+		logger.Error("Error generating msp", err)
+		return nil, err
+	}
+	consenter, err := raftConsenter(orgCA, orderer)
+	if err != nil {
+		return nil, err
+	}
+	return c.updateConfigs(c.consenterChannels(channelNames), dryRun, func(channelName string, config *cb.Config) error {
+		return addConsenter(config, consenter, orderer.ExternalEndpoint)
+	})
+}
+
+// RemoveConsenter removes orderer from the etcdraft consenters and the
+// orderer addresses of the system channel and of channelNames, the
+// channels it doesn't serve are skipped. A channel whose remaining
+// consenters would be less than a quorum of the current ones is refused
+func (c *Channel) RemoveConsenter(orderer *ServiceNode, channelNames []string, dryRun bool) ([]*ConfigUpdatePreview, error) {
+	if orderer == nil || orderer.ID == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "orderer should not be empty")
+	}
+	host, port, err := consenterAddress(orderer)
+	if err != nil {
+		return nil, err
+	}
+	return c.updateConfigs(c.consenterChannels(channelNames), dryRun, func(channelName string, config *cb.Config) error {
+		return removeConsenter(config, host, port, orderer.ExternalEndpoint)
+	})
+}
+
+// consenterChannels returns channelNames with the system channel, which
+// every consenter serves
+func (c *Channel) consenterChannels(channelNames []string) []string {
+	for _, name := range channelNames {
+		if name == c.network.systemChannel() {
+			return channelNames
+		}
+	}
+	return append([]string{c.network.systemChannel()}, channelNames...)
+}
+
+// options checks o and converts it, the zero options are left to the
+// defaults of the sdk
+func (o *RaftOptions) options() (etcdraft.Options, error) {
+	if o.TickInterval != "" {
+		tick, err := time.ParseDuration(o.TickInterval)
+		if err != nil {
+			return etcdraft.Options{}, protocols.WrapError(protocols.CodeBadRequest, err)
+		}
+		if tick <= 0 {
+			return etcdraft.Options{}, protocols.Errorf(protocols.CodeBadRequest, "tick interval %s should be positive", o.TickInterval)
+		}
+	}
+	return etcdraft.Options{
+		TickInterval:         o.TickInterval,
+		ElectionTick:         o.ElectionTick,
+		SnapshotIntervalSize: o.SnapshotIntervalSize,
+	}, nil
+}
+
+// raftConsenters returns the consenter set of the orderer nodes of orgs,
+// each with the TLS cert GenerateCrypto issued for it
+func raftConsenters(orgs []*OrgInfo) ([]*etcdraft.Consenter, error) {
+	var consenters []*etcdraft.Consenter
+	for _, org := range orgs {
+		for _, node := range org.OrdererNodes {
+			consenter, err := raftConsenter(org.OrgCA, node)
+			if err != nil {
+				return nil, err
+			}
+			consenters = append(consenters, consenter)
+		}
+	}
+	if len(consenters) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "etcdraft orderers need orderer nodes")
+	}
+	return consenters, nil
+}
+
+// raftConsenter returns node as a consenter, it listens on its external
+// endpoint and uses its TLS cert as server and client
+func raftConsenter(orgCA *sdk.CA, node *ServiceNode) (*etcdraft.Consenter, error) {
+	host, port, err := consenterAddress(node)
+	if err != nil {
+		return nil, err
+	}
+	cert, err := orgCA.NodeTLSCert(node.ID, sdk.OrdererNode)
+	if os.IsNotExist(err) {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "TLS cert of orderer %s not found, its crypto should be generated first", node.ID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &etcdraft.Consenter{
+		Host:          host,
+		Port:          port,
+		ClientTlsCert: cert,
+		ServerTlsCert: cert,
+	}, nil
+}
+
+// consenterAddress splits the external endpoint of node
+func consenterAddress(node *ServiceNode) (string, uint32, error) {
+	host, port, err := net.SplitHostPort(node.ExternalEndpoint)
+	if err != nil {
+		return "", 0, protocols.Errorf(protocols.CodeBadRequest, "bad endpoint %s of orderer %s: %s", node.ExternalEndpoint, node.ID, err)
+	}
+	portNum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return "", 0, protocols.Errorf(protocols.CodeBadRequest, "bad port of orderer %s: %s", node.ID, err)
+	}
+	return host, uint32(portNum), nil
+}
+
+// addConsenter adds consenter to the etcdraft consenters of config and
+// address to its orderer addresses, errConfigUnchanged if it's already a
+// consenter
+func addConsenter(config *cb.Config, consenter *etcdraft.Consenter, address string) error {
+	consensus, metadata, err := raftMetadata(config)
+	if err != nil {
+		return err
+	}
+	for _, existing := range metadata.Consenters {
+		if existing.Host == consenter.Host && existing.Port == consenter.Port {
+			return errConfigUnchanged
+		}
+	}
+	metadata.Consenters = append(metadata.Consenters, consenter)
+	if err := setRaftMetadata(config, consensus, metadata); err != nil {
+		return err
+	}
+	return setOrdererAddress(config, address, true)
+}
+
+// removeConsenter removes the consenter listening on host:port from config
+// and address from its orderer addresses, errConfigUnchanged if there is no
+// such consenter. The remaining consenters must be a quorum of the current
+// ones, or the cluster couldn't agree on the removal
+func removeConsenter(config *cb.Config, host string, port uint32, address string) error {
+	consensus, metadata, err := raftMetadata(config)
+	if err != nil {
+		return err
+	}
+	var consenters []*etcdraft.Consenter
+	for _, existing := range metadata.Consenters {
+		if existing.Host != host || existing.Port != port {
+			consenters = append(consenters, existing)
+		}
+	}
+	if len(consenters) == len(metadata.Consenters) {
+		return errConfigUnchanged
+	}
+	if quorum := len(metadata.Consenters)/2 + 1; len(consenters) < quorum {
+		return protocols.Errorf(protocols.CodeBadRequest, "removing consenter %s:%d would leave %d of %d consenters, less than the quorum %d", host, port, len(consenters), len(metadata.Consenters), quorum)
+	}
+	metadata.Consenters = consenters
+	if err := setRaftMetadata(config, consensus, metadata); err != nil {
+		return err
+	}
+	return setOrdererAddress(config, address, false)
+}
+
+// raftMetadata returns the consensus type of config and its etcdraft
+// metadata, the orderers of config must be etcdraft ones
+func raftMetadata(config *cb.Config) (*ab.ConsensusType, *etcdraft.ConfigMetadata, error) {
+	orderer, ok := config.ChannelGroup.Groups[channelconfig.OrdererGroupKey]
+	if !ok {
+		return nil, nil, protocols.Errorf(protocols.CodeBadRequest, "config has no orderer group")
+	}
+	consensus := &ab.ConsensusType{}
+	if err := unmarshalConfigValue(orderer, channelconfig.ConsensusTypeKey, consensus); err != nil {
+		return nil, nil, err
+	}
+	if consensus.Type != etcdraftConsensusType {
+		return nil, nil, protocols.Errorf(protocols.CodeBadRequest, "orderers are %s, not etcdraft", consensus.Type)
+	}
+	metadata := &etcdraft.ConfigMetadata{}
+	if err := proto.Unmarshal(consensus.Metadata, metadata); err != nil {
+		return nil, nil, err
+	}
+	return consensus, metadata, nil
+}
+
+func setRaftMetadata(config *cb.Config, consensus *ab.ConsensusType, metadata *etcdraft.ConfigMetadata) error {
+	data, err := proto.Marshal(metadata)
+	if err != nil {
+		return err
+	}
+	consensus.Metadata = data
+	return marshalConfigValue(config.ChannelGroup.Groups[channelconfig.OrdererGroupKey], channelconfig.ConsensusTypeKey, consensus)
+}
+
+// setOrdererAddress adds address to the orderer addresses of config if
+// present, or removes it
+func setOrdererAddress(config *cb.Config, address string, present bool) error {
+	oa := &cb.OrdererAddresses{}
+	if err := unmarshalConfigValue(config.ChannelGroup, channelconfig.OrdererAddressesKey, oa); err != nil {
+		return err
+	}
+	var addresses []string
+	found := false
+	for _, addr := range oa.Addresses {
+		if addr == address {
+			if !present {
+				continue
+			}
+			found = true
+		}
+		addresses = append(addresses, addr)
+	}
+	if present && !found {
+		addresses = append(addresses, address)
+	}
+	oa.Addresses = addresses
+	return marshalConfigValue(config.ChannelGroup, channelconfig.OrdererAddressesKey, oa)
+}
@@ -0,0 +1,180 @@
+package channel
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	ab "github.com/hyperledger/fabric/protos/orderer"
+	"github.com/hyperledger/fabric/protos/orderer/etcdraft"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+func TestRaftGenesisBlock(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	var orgs []*OrgInfo
+	for i, name := range []string{"raftorg1", "raftorg2"} {
+		orgCA, err := sdk.NewCA(path.Join(dir, name), name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		node := &ServiceNode{ID: "orderer0." + name, ExternalEndpoint: "127.0.0.1:" + []string{"7050", "8050"}[i]}
+		if err := orgCA.GenerateMSP([]*sdk.CertConfig{{CN: node.ID, SAN: []string{"127.0.0.1"}, NodeType: sdk.OrdererNode}}, nil); err != nil {
+			t.Fatal(err)
+		}
+		orgs = append(orgs, &OrgInfo{OrgName: name, OrgMSP: name, OrgCA: orgCA, OrdererNodes: []*ServiceNode{node}})
+	}
+
+	block, err := GenGenesisBlock(&GenGenesisBlockRequest{
+		Orgs:        orgs,
+		OrdererType: etcdraftConsensusType,
+		EtcdRaft:    RaftOptions{ElectionTick: 20},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := configFromBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consensus := &ab.ConsensusType{}
+	if err := unmarshalConfigValue(config.ChannelGroup.Groups[channelconfig.OrdererGroupKey], channelconfig.ConsensusTypeKey, consensus); err != nil {
+		t.Fatal(err)
+	}
+	metadata := &etcdraft.ConfigMetadata{}
+	if err := proto.Unmarshal(consensus.Metadata, metadata); err != nil {
+		t.Fatal(err)
+	}
+	if consensus.Type != etcdraftConsensusType || len(metadata.Consenters) != 2 {
+		t.Fatalf("unexpected consensus %s with %d consenters", consensus.Type, len(metadata.Consenters))
+	}
+	cert, err := orgs[1].OrgCA.NodeTLSCert("orderer0.raftorg2", sdk.OrdererNode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	consenter := metadata.Consenters[1]
+	if consenter.Host != "127.0.0.1" || consenter.Port != 8050 || !bytes.Equal(consenter.ServerTlsCert, cert) || !bytes.Equal(consenter.ClientTlsCert, cert) {
+		t.Fatalf("unexpected consenter %s:%d", consenter.Host, consenter.Port)
+	}
+	if options := metadata.Options; options.ElectionTick != 20 || options.TickInterval != "500ms" || options.SnapshotIntervalSize == 0 {
+		t.Fatalf("unexpected options %v", options)
+	}
+
+	orgs[1].OrdererNodes = append(orgs[1].OrdererNodes, &ServiceNode{ID: "orderer1.raftorg2", ExternalEndpoint: "127.0.0.1:9050"})
+	if _, err := GenGenesisBlock(&GenGenesisBlockRequest{Orgs: orgs, OrdererType: etcdraftConsensusType}); err == nil {
+		t.Error("expected error for an orderer without TLS cert")
+	}
+	if _, err := GenGenesisBlock(&GenGenesisBlockRequest{Orgs: orgs, OrdererType: etcdraftConsensusType, Kafkas: []string{"kafka0:9092"}}); err == nil {
+		t.Error("expected error for etcdraft with kafka brokers")
+	}
+	if _, err := GenGenesisBlock(&GenGenesisBlockRequest{Orgs: orgs[:1], OrdererType: etcdraftConsensusType, EtcdRaft: RaftOptions{TickInterval: "fast"}}); err == nil {
+		t.Error("expected error for a bad tick interval")
+	}
+}
+
+func TestConsenters(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	orgCA, err := sdk.NewCA(path.Join(dir, "consenterorg"), "consenterorg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	org := &OrgInfo{OrgName: "consenterorg", OrgMSP: "consenterorg", OrgCA: orgCA}
+	var nodes []*ServiceNode
+	for _, port := range []string{"7050", "8050", "9050", "10050"} {
+		node := &ServiceNode{ID: "orderer" + port + ".consenterorg", ExternalEndpoint: "127.0.0.1:" + port}
+		if err := orgCA.GenerateMSP([]*sdk.CertConfig{{CN: node.ID, SAN: []string{"127.0.0.1"}, NodeType: sdk.OrdererNode}}, nil); err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+	org.OrdererNodes = nodes[:3]
+	block, err := GenGenesisBlock(&GenGenesisBlockRequest{Orgs: []*OrgInfo{org}, OrdererType: etcdraftConsensusType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	config, err := configFromBlock(block)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	consenters := func() []*etcdraft.Consenter {
+		_, metadata, err := raftMetadata(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return metadata.Consenters
+	}
+	addresses := func() []string {
+		addresses, _, err := ordererInfo(config)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return addresses
+	}
+
+	consenter, err := raftConsenter(orgCA, nodes[3])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := addConsenter(config, consenter, nodes[3].ExternalEndpoint); err != nil {
+		t.Fatal(err)
+	}
+	if n := len(consenters()); n != 4 || consenters()[3].Port != 10050 {
+		t.Fatalf("unexpected %d consenters after adding one", n)
+	}
+	if a := addresses(); len(a) != 4 || a[3] != "127.0.0.1:10050" {
+		t.Fatalf("unexpected orderer addresses %v", a)
+	}
+	if err := addConsenter(config, consenter, nodes[3].ExternalEndpoint); err != errConfigUnchanged {
+		t.Fatalf("expected errConfigUnchanged adding a consenter twice, got %v", err)
+	}
+
+	// 4 consenters have a quorum of 3, 3 of 2, so the third removal fails
+	for i, node := range nodes[:3] {
+		host, port, err := consenterAddress(node)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = removeConsenter(config, host, port, node.ExternalEndpoint)
+		if i < 2 && err != nil {
+			t.Fatal(err)
+		}
+		if i == 2 && err == nil {
+			t.Fatal("expected error removing a consenter below quorum")
+		}
+	}
+	if n := len(consenters()); n != 2 {
+		t.Fatalf("unexpected %d consenters after removals", n)
+	}
+	if a := addresses(); len(a) != 2 || a[0] != "127.0.0.1:9050" {
+		t.Fatalf("unexpected orderer addresses %v", a)
+	}
+	if err := removeConsenter(config, "127.0.0.1", 7050, "127.0.0.1:7050"); err != errConfigUnchanged {
+		t.Fatalf("expected errConfigUnchanged removing a missing consenter, got %v", err)
+	}
+
+	solo, err := GenGenesisBlock(&GenGenesisBlockRequest{Orgs: []*OrgInfo{org}, OrdererType: soloConsensusType})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if config, err = configFromBlock(solo); err != nil {
+		t.Fatal(err)
+	}
+	if err := addConsenter(config, consenter, nodes[3].ExternalEndpoint); err == nil {
+		t.Error("expected error adding a consenter to solo orderers")
+	}
+}
@@ -0,0 +1,50 @@
+package channel
+
+import (
+	"manageChain/protocols"
+	"regexp"
+
+	"github.com/hyperledger/fabric/sdk"
+	"github.com/pkg/errors"
+)
+
+// adminUserName is the user the sdk issues the admin of an org to
+const adminUserName = "Admin"
+
+var userNamePattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_.-]*$`)
+
+// GenerateUsers issues the client identities of users by orgCA, those
+// already issued are kept unless their OUs differ, and returns all the
+// identities of the org
+func GenerateUsers(orgCA *sdk.CA, users []*UserConfig) ([]*sdk.User, error) {
+	if err := validateUsers(users); err != nil {
+		return nil, err
+	}
+	var configs []*sdk.UserConfig
+	for _, user := range users {
+		configs = append(configs, &sdk.UserConfig{
+			Name: user.Name,
+			OUs:  user.OUs,
+		})
+	}
+	if err := orgCA.GenerateUsers(configs); err != nil {
+		logger.Error("Error generating users", err)
+		if errors.Cause(err) == sdk.ErrUserConflict {
+			return nil, protocols.WrapError(protocols.CodeConflict, err)
+		}
+		return nil, err
+	}
+	return orgCA.Users()
+}
+
+func validateUsers(users []*UserConfig) error {
+	for _, user := range users {
+		if user == nil || !userNamePattern.MatchString(user.Name) {
+			return protocols.Errorf(protocols.CodeBadRequest, "invalid user name, should be letters, digits, '_', '.' or '-'")
+		}
+		if user.Name == adminUserName {
+			return protocols.Errorf(protocols.CodeBadRequest, "user %s is issued with the org", adminUserName)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package channel
+
+import (
+	"io/ioutil"
+	"manageChain/protocols"
+	"os"
+	"path"
+	"testing"
+
+	"github.com/hyperledger/fabric/sdk"
+)
+
+func TestGenerateUsers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "msp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	orgCA, err := sdk.NewCA(path.Join(dir, "testorg1"), "testorg1")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	users, err := GenerateUsers(orgCA, []*UserConfig{
+		{Name: "app1"},
+		{Name: "app2", OUs: []string{"client", "payments"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]*sdk.User)
+	for _, user := range users {
+		byName[user.Name] = user
+	}
+	if len(users) != 3 || !byName["Admin@testorg1"].Admin {
+		t.Fatalf("unexpected users %v", users)
+	}
+	app2, ok := byName["app2@testorg1"]
+	if !ok || len(app2.OUs) != 2 || app2.OUs[1] != "payments" || !app2.NotAfter.After(app2.NotBefore) {
+		t.Fatalf("unexpected user %v", app2)
+	}
+
+	if _, err := GenerateUsers(orgCA, []*UserConfig{{Name: "app2", OUs: []string{"client", "payments"}}}); err != nil {
+		t.Fatalf("expected app2 kept with the same OUs: %v", err)
+	}
+	for _, user := range []*UserConfig{{Name: "app1", OUs: []string{"client"}}, {Name: "app2", OUs: []string{"client"}}, {Name: "app2"}} {
+		_, err := GenerateUsers(orgCA, []*UserConfig{user})
+		if e, ok := err.(*protocols.Error); !ok || e.Code != protocols.CodeConflict {
+			t.Errorf("expected conflict issuing %s with OUs %v, got %v", user.Name, user.OUs, err)
+		}
+	}
+
+	for _, user := range []*UserConfig{nil, {Name: ""}, {Name: "../app"}, {Name: "Admin"}} {
+		if _, err := GenerateUsers(orgCA, []*UserConfig{user}); err == nil {
+			t.Errorf("expected error issuing %v", user)
+		}
+	}
+}
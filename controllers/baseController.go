@@ -2,6 +2,9 @@ package controllers
 
 import (
 	// "fmt"
+	"manageChain/auth"
+	"manageChain/channel"
+	"manageChain/jobs"
 	"manageChain/protocols"
 
 	"github.com/astaxie/beego"
@@ -12,9 +15,14 @@ type BaseController struct {
 	beego.Controller
 }
 
+// principal returns the caller authenticated by the auth filter
+func (c *BaseController) principal() *auth.Principal {
+	return auth.FromContext(c.Ctx)
+}
+
 func (c *BaseController) ReturnErrorCode(code string, msg string) {
 	logger.Error("Code: ", code)
-	c.Ctx.Output.SetStatus(500)
+	c.Ctx.Output.SetStatus(protocols.HTTPStatus(protocols.ErrorCode(code)))
 	c.Data["json"] = &protocols.ErrorMessage{
 		Code:    code,
 		Message: msg,
@@ -22,19 +30,99 @@ func (c *BaseController) ReturnErrorCode(code string, msg string) {
 	c.ServeJSON()
 }
 
-// ReturnErrorMsg return given message to the front end
+// ReturnErrorMsg return given message to the front end, the status
+// depends on the code of err
 func (c *BaseController) ReturnErrorMsg(err error) {
 	logger.Error("Got error: ", err)
-	c.Ctx.Output.SetStatus(500)
-	c.Data["json"] = &protocols.ErrorMessage{
-		Message: err.Error(),
-	}
+	status, msg := protocols.ToMessage(err)
+	c.Ctx.Output.SetStatus(status)
+	c.Data["json"] = msg
+	c.ServeJSON()
+}
+
+// ReturnErrorResult returns err along with result, what was done before
+// err happened, if any
+func (c *BaseController) ReturnErrorResult(err error, result interface{}) {
+	logger.Error("Got error: ", err)
+	status, msg := protocols.ToMessage(err)
+	msg.Result = result
+	c.Ctx.Output.SetStatus(status)
+	c.Data["json"] = msg
 	c.ServeJSON()
 }
 
+// ReturnBadRequest tells the front end the request itself is wrong
+func (c *BaseController) ReturnBadRequest(err error) {
+	c.ReturnErrorMsg(protocols.WrapError(protocols.CodeBadRequest, err))
+}
+
 func (c *BaseController) ReturnOKMsg(data interface{}) {
 	// logger.Debug("Got normal response: ", data)
 	c.Ctx.Output.SetStatus(200)
 	c.Data["json"] = data
 	c.ServeJSON()
 }
+
+// ReturnAcceptedMsg tells the front end the request goes on in background
+func (c *BaseController) ReturnAcceptedMsg(data interface{}) {
+	c.Ctx.Output.SetStatus(202)
+	c.Data["json"] = data
+	c.ServeJSON()
+}
+
+// Serve runs op and returns its result, or submits it as a job when the
+// request has "async=true", the job can then be polled at /jobs/:id and
+// "webhook" gets it posted once done. Only the caller may see the job
+func (c *BaseController) Serve(typ string, op jobs.Operation) {
+	async, err := c.GetBool("async", false)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return
+	}
+	if !async {
+		result, err := op(func(string) {})
+		if err != nil {
+			c.ReturnErrorResult(err, result)
+			return
+		}
+		c.ReturnOKMsg(result)
+		return
+	}
+
+	job, err := jobs.Default().Submit(typ, c.principal().Name, c.GetString("webhook"), op)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return
+	}
+	logger.Info("submitted %s job %s", typ, job.ID)
+	c.ReturnAcceptedMsg(job)
+}
+
+// ServeConfigUpdate returns the result of preview when the request has
+// "dryRun=true", nothing is signed nor broadcast then. Otherwise apply is
+// served as by Serve, reporting the steps of ch
+func (c *BaseController) ServeConfigUpdate(typ string, ch *channel.Channel, preview, apply func() (interface{}, error)) {
+	dryRun, err := c.GetBool("dryRun", false)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return
+	}
+	if dryRun {
+		result, err := preview()
+		if err != nil {
+			c.ReturnErrorResult(err, result)
+			return
+		}
+		c.ReturnOKMsg(result)
+		return
+	}
+	c.Serve(typ, func(step func(string)) (interface{}, error) {
+		ch.OnStep(step)
+		result, err := apply()
+		if err != nil {
+			return result, err
+		}
+		logger.Info("successfully %s", typ)
+		return result, nil
+	})
+}
@@ -2,9 +2,8 @@ package controllers
 
 import (
 	"encoding/json"
+	"manageChain/auth"
 	"manageChain/chaincode"
-	"manageChain/channel"
-	"path"
 	"time"
 
 	"github.com/astaxie/beego"
@@ -22,7 +21,7 @@ func (c *ChaincodeController) InstallChaincode() error {
 	icq := &chaincode.InstallChaincodeRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, icq)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 
@@ -32,7 +31,7 @@ func (c *ChaincodeController) InstallChaincode() error {
 	ccName := icq.CcName
 	ccVersion := icq.CcVersion
 
-	newchaincode, err := newChaincode(org, ccTarPath, ccPath, ccName, ccVersion)
+	newchaincode, err := newChaincode(c.principal(), org, ccTarPath, ccPath, ccName, ccVersion)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
@@ -41,22 +40,22 @@ func (c *ChaincodeController) InstallChaincode() error {
 	orgCA := newchaincode.GetOrgCA()
 	endorsers := serviceNodesToEndpointList(icq.PeerNodes, chaincode.InstallChaincodeTimeout, orgCA.TLSCACert())
 
-	err = newchaincode.InstallChaincode(endorsers)
-	if err != nil {
-		c.ReturnErrorMsg(err)
-		return nil
-	}
-
-	c.ReturnOKMsg("OK")
-	logger.Info("successfully Install Chaincode")
+	c.Serve("install chaincode", func(step func(string)) (interface{}, error) {
+		newchaincode.OnStep(step)
+		if err := newchaincode.InstallChaincode(endorsers); err != nil {
+			return nil, err
+		}
+		logger.Info("successfully Install Chaincode")
+		return "OK", nil
+	})
 	return nil
 }
 
-func newChaincode(org string, ccTarPath string, ccPath string, ccName string, ccVersion string) (*chaincode.Chaincode, error) {
+func newChaincode(p *auth.Principal, org string, ccTarPath string, ccPath string, ccName string, ccVersion string) (*chaincode.Chaincode, error) {
 	mspDir := beego.AppConfig.String("MSPDir")
 	gm, _ := beego.AppConfig.Bool("GM")
 
-	orgCA, err := channel.GetCA(path.Join(mspDir, org), org)
+	orgCA, err := getOrgCA(p, mspDir, org)
 	if err != nil {
 		logger.Error("Error getting peer ca", err)
 		return nil, err
@@ -71,7 +70,7 @@ func (c *ChaincodeController) InstantiateChaincode() error {
 	icq := &chaincode.InstantiateChaincodeRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, icq)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 	org := icq.Org
@@ -80,7 +79,7 @@ func (c *ChaincodeController) InstantiateChaincode() error {
 	ccName := icq.CcName
 	ccVersion := icq.CcVersion
 
-	newchaincode, err := newChaincode(org, ccTarPath, ccPath, ccName, ccVersion)
+	newchaincode, err := newChaincode(c.principal(), org, ccTarPath, ccPath, ccName, ccVersion)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
@@ -92,14 +91,56 @@ func (c *ChaincodeController) InstantiateChaincode() error {
 	orgCA := newchaincode.GetOrgCA()
 	endorsers := serviceNodesToEndpointList(icq.PeerNodes, chaincode.InstantiateChaincodeTimeout, orgCA.TLSCACert())
 	casters := serviceNodesToEndpointList(icq.OrdererNodes, chaincode.InstantiateChaincodeTimeout, orgCA.TLSCACert())
-	err = newchaincode.InstantiateChaincode(endorsers, casters, channelName, policy, args)
+	c.Serve("instantiate chaincode", func(step func(string)) (interface{}, error) {
+		newchaincode.OnStep(step)
+		if err := newchaincode.InstantiateChaincode(endorsers, casters, channelName, policy, args); err != nil {
+			return nil, err
+		}
+		logger.Info("successfully Instantiate Chaincode")
+		return "OK", nil
+	})
+	return nil
+}
+
+func (c *ChaincodeController) UpgradeChaincode() error {
+	logger.Info("start Upgrade Chaincode")
+
+	ucq := &chaincode.UpgradeChaincodeRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, ucq)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	org := ucq.Org
+	ccTarPath := ""
+	ccPath := ""
+	ccName := ucq.CcName
+	ccVersion := ucq.CcVersion
+
+	newchaincode, err := newChaincode(c.principal(), org, ccTarPath, ccPath, ccName, ccVersion)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
 	}
 
-	c.ReturnOKMsg("OK")
-	logger.Info("successfully Instantiate Chaincode")
+	channelName := ucq.ChannelName
+	policy := ucq.Policy
+	args := ucq.Args
+	var collection []byte
+	if ucq.CollectionConfig != "" {
+		collection = []byte(ucq.CollectionConfig)
+	}
+	orgCA := newchaincode.GetOrgCA()
+	endorsers := serviceNodesToEndpointList(ucq.PeerNodes, chaincode.InstantiateChaincodeTimeout, orgCA.TLSCACert())
+	casters := serviceNodesToEndpointList(ucq.OrdererNodes, chaincode.InstantiateChaincodeTimeout, orgCA.TLSCACert())
+	c.Serve("upgrade chaincode", func(step func(string)) (interface{}, error) {
+		newchaincode.OnStep(step)
+		if err := newchaincode.UpgradeChaincode(endorsers, casters, channelName, policy, collection, args); err != nil {
+			return nil, err
+		}
+		logger.Info("successfully Upgrade Chaincode")
+		return "OK", nil
+	})
 	return nil
 }
 
@@ -109,7 +150,7 @@ func (c *ChaincodeController) Invoke() error {
 	iq := &chaincode.InvokeRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, iq)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 	org := iq.Org
@@ -118,7 +159,7 @@ func (c *ChaincodeController) Invoke() error {
 	ccName := iq.CcName
 	ccVersion := ""
 
-	newchaincode, err := newChaincode(org, ccTarPath, ccPath, ccName, ccVersion)
+	newchaincode, err := newChaincode(c.principal(), org, ccTarPath, ccPath, ccName, ccVersion)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
@@ -130,17 +171,105 @@ func (c *ChaincodeController) Invoke() error {
 	orgCA := newchaincode.GetOrgCA()
 	endorsers := serviceNodesToEndpointList(iq.PeerNodes, chaincode.InstantiateChaincodeTimeout, orgCA.TLSCACert())
 	casters := serviceNodesToEndpointList(iq.OrdererNodes, chaincode.InstantiateChaincodeTimeout, orgCA.TLSCACert())
-	err = newchaincode.Invoke(channelName, endorsers, casters, args)
+	resp, err := newchaincode.Invoke(channelName, endorsers, casters, args)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
 	}
 
-	c.ReturnOKMsg("OK")
+	c.ReturnOKMsg(resp)
 	logger.Info("successfully Invoke Chaincode")
 	return nil
 }
 
+func (c *ChaincodeController) Query() error {
+	logger.Info("start Query Chaincode")
+
+	qq := &chaincode.QueryRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, qq)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	org := qq.Org
+	ccTarPath := ""
+	ccPath := ""
+	ccName := qq.CcName
+	ccVersion := ""
+
+	newchaincode, err := newChaincode(c.principal(), org, ccTarPath, ccPath, ccName, ccVersion)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	channelName := qq.ChannelName
+	args := qq.Args
+
+	orgCA := newchaincode.GetOrgCA()
+	endorsers := serviceNodesToEndpointList(qq.PeerNodes, chaincode.QueryChaincodeTimeout, orgCA.TLSCACert())
+	resps, err := newchaincode.Query(channelName, endorsers, args)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	c.ReturnOKMsg(resps)
+	logger.Info("successfully Query Chaincode")
+	return nil
+}
+
+func (c *ChaincodeController) InstalledChaincodes() error {
+	logger.Info("start list installed Chaincodes")
+
+	lcq := &chaincode.ListChaincodeRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, lcq)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+
+	newchaincode, err := newChaincode(c.principal(), lcq.Org, "", "", "", "")
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	orgCA := newchaincode.GetOrgCA()
+	endorsers := serviceNodesToEndpointList(lcq.PeerNodes, chaincode.QueryChaincodeTimeout, orgCA.TLSCACert())
+	c.ReturnOKMsg(newchaincode.InstalledChaincodes(endorsers))
+	logger.Info("successfully list installed Chaincodes")
+	return nil
+}
+
+func (c *ChaincodeController) InstantiatedChaincodes() error {
+	logger.Info("start list instantiated Chaincodes")
+
+	lcq := &chaincode.ListChaincodeRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, lcq)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+
+	newchaincode, err := newChaincode(c.principal(), lcq.Org, "", "", "", "")
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	orgCA := newchaincode.GetOrgCA()
+	endorsers := serviceNodesToEndpointList(lcq.PeerNodes, chaincode.QueryChaincodeTimeout, orgCA.TLSCACert())
+	list, err := newchaincode.InstantiatedChaincodes(lcq.ChannelName, endorsers)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(list)
+	logger.Info("successfully list instantiated Chaincodes")
+	return nil
+}
+
 func serviceNodesToEndpointList(serviceNodes []*chaincode.ServiceNode, timeout time.Duration, cert []byte) []*sdk.Endpoint {
 	var endpoints []*sdk.Endpoint
 	for _, sn := range serviceNodes {
@@ -2,27 +2,33 @@ package controllers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
+	"manageChain/auth"
 	"manageChain/channel"
+	"manageChain/protocols"
 	"net/url"
+	"os"
 	"path"
 	"strconv"
 
 	"github.com/astaxie/beego"
 	logger "github.com/astaxie/beego/logs"
 	"github.com/hyperledger/fabric/common/tools/configtxgen/localconfig"
+	"github.com/hyperledger/fabric/sdk"
 )
 
 type ChannelController struct {
 	BaseController
 }
 
-func newChannel(orgs []*channel.OrgInfo) (*channel.Channel, error) {
+func newChannel(p *auth.Principal, orgs []*channel.OrgInfo) (*channel.Channel, error) {
 	mspDir := beego.AppConfig.String("MSPDir")
 	gm, _ := beego.AppConfig.Bool("GM")
 	var orginfo []*channel.OrgInfo
 
 	for _, org := range orgs {
-		orgCA, err := channel.GetCA(path.Join(mspDir, org.OrgName), org.OrgName)
+		orgCA, err := getOrgCA(p, mspDir, org.OrgName)
 		if err != nil {
 			logger.Error("Error getting peer ca", err)
 			return nil, err
@@ -34,30 +40,79 @@ func newChannel(orgs []*channel.OrgInfo) (*channel.Channel, error) {
 	return channel.NewChannel(orginfo, gm)
 }
 
+// getOrgCA loads the msp generated for org if p may act as it, unlike
+// channel.GetCA it never creates a new one
+func getOrgCA(p *auth.Principal, mspDir string, org string) (*sdk.CA, error) {
+	if err := p.Authorize(org); err != nil {
+		return nil, err
+	}
+	dir := path.Join(mspDir, org)
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		return nil, protocols.Errorf(protocols.CodeMSPNotFound, "msp of org %s not found", org)
+	}
+	return channel.GetCA(dir, org)
+}
+
+func orgNames(orgs []*channel.OrgInfo) (names []string) {
+	for _, org := range orgs {
+		names = append(names, org.OrgName)
+	}
+	return
+}
+
+// orgFromQuery builds the org of a GET request, the peers are given by the
+// "peer" parameters or taken from the org section of app.conf
+func (c *ChannelController) orgFromQuery() *channel.OrgInfo {
+	org := c.GetString("org")
+	peers := c.GetStrings("peer")
+	if len(peers) == 0 {
+		peers = beego.AppConfig.Strings(org + "::Peers")
+	}
+	orderers := c.GetStrings("orderer")
+	if len(orderers) == 0 {
+		orderers = beego.AppConfig.Strings(org + "::Orderers")
+	}
+
+	orgInfo := &channel.OrgInfo{
+		OrgName: org,
+		OrgMSP:  org,
+		MspID:   org,
+	}
+	for _, peer := range peers {
+		orgInfo.PeerNodes = append(orgInfo.PeerNodes, &channel.ServiceNode{Endpoint: peer, ExternalEndpoint: peer})
+	}
+	for _, orderer := range orderers {
+		orgInfo.OrdererNodes = append(orgInfo.OrdererNodes, &channel.ServiceNode{Endpoint: orderer, ExternalEndpoint: orderer})
+	}
+	return orgInfo
+}
+
 func (c *ChannelController) CreateChannel() error {
 	logger.Info("start create channel")
 
 	ccr := &channel.NewCreateChannelRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, ccr)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 
 	channelName := ccr.ChannelName
-	channel, err := newChannel(ccr.Orgs)
+	channel, err := newChannel(c.principal(), ccr.Orgs)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
 	}
+	channel.SetNetwork(&ccr.NetworkConfig)
 
-	err = channel.CreateChannel(channelName)
-	if err != nil {
-		c.ReturnErrorMsg(err)
-		return nil
-	}
-	c.ReturnOKMsg("OK")
-	logger.Info("successfully create channel")
+	c.Serve("create channel", func(step func(string)) (interface{}, error) {
+		channel.OnStep(step)
+		if err := channel.CreateChannel(channelName, ccr.Policies, &ccr.Capabilities); err != nil {
+			return nil, err
+		}
+		logger.Info("successfully create channel")
+		return "OK", nil
+	})
 	return nil
 }
 
@@ -67,24 +122,25 @@ func (c *ChannelController) JoinChannel() error {
 	jcr := &channel.JoinChannelRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, jcr)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 
 	channelName := jcr.ChannelName
-	channel, err := newChannel(jcr.Orgs)
+	channel, err := newChannel(c.principal(), jcr.Orgs)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
 	}
 
-	err = channel.JoinChannel(channelName)
-	if err != nil {
-		c.ReturnErrorMsg(err)
-		return nil
-	}
-	c.ReturnOKMsg("OK")
-	logger.Info("successfully join channel")
+	c.Serve("join channel", func(step func(string)) (interface{}, error) {
+		channel.OnStep(step)
+		if err := channel.JoinChannel(channelName); err != nil {
+			return nil, err
+		}
+		logger.Info("successfully join channel")
+		return "OK", nil
+	})
 	return nil
 }
 
@@ -93,32 +149,91 @@ func (c *ChannelController) GenCrypto() error {
 	genCryptoReq := &channel.GenCryptoRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, genCryptoReq)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 	orgs := genCryptoReq.Orgs
-	err = channel.GenerateCrypto(orgs)
+	if err := c.principal().Authorize(orgNames(orgs)...); err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	err = channel.GenerateCrypto(orgs, genCryptoReq.Users)
 	if err != nil {
 		logger.Error("Error generate crypto")
 		c.ReturnErrorMsg(err)
 		return nil
 	}
 
+	users := make(map[string][]*sdk.User)
+	for _, org := range orgs {
+		if users[org.OrgName], err = org.OrgCA.Users(); err != nil {
+			c.ReturnErrorMsg(err)
+			return nil
+		}
+	}
+	c.ReturnOKMsg(users)
 	logger.Info("end generate crypto config")
 	return nil
 }
 
+// AddUsers issues client identities for the existing msp of an org
+func (c *ChannelController) AddUsers() error {
+	logger.Info("start add users")
+	req := &channel.UsersRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Users) == 0 {
+		c.ReturnBadRequest(errors.New("users should not be empty"))
+		return nil
+	}
+	orgCA, err := getOrgCA(c.principal(), beego.AppConfig.String("MSPDir"), req.Org)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	users, err := channel.GenerateUsers(orgCA, req.Users)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(users)
+	logger.Info("end add users")
+	return nil
+}
+
+// Users lists the identities of an org with the expiry of their certs
+func (c *ChannelController) Users() error {
+	orgCA, err := getOrgCA(c.principal(), beego.AppConfig.String("MSPDir"), c.Ctx.Input.Param(":org"))
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	users, err := orgCA.Users()
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(users)
+	return nil
+}
+
 // Generate genesis block
 func (c *ChannelController) GenGenesisBlock() error {
 	logger.Info("start generate Genesis block")
 	genGbReq := &channel.GenGenesisBlockRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, genGbReq)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 	orgs := genGbReq.Orgs
-	kafkas := genGbReq.Kafkas
+	if err := c.principal().Authorize(orgNames(orgs)...); err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
 
 	var orginfos []*channel.OrgInfo
 	mspDir := beego.AppConfig.String("MSPDir")
@@ -132,18 +247,73 @@ func (c *ChannelController) GenGenesisBlock() error {
 		org.OrgCA = orgCA
 		orginfos = append(orginfos, org)
 	}
-	block, err := channel.GenGenesisBlock(orginfos, kafkas)
+	network, err := channel.NetworkName(genGbReq.Network, orgs)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	decode, err := c.GetBool("decode", false)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	genGbReq.Orgs = orginfos
+	block, err := channel.GenGenesisBlock(genGbReq)
 	if err != nil {
 		logger.Error("error generate genesis block:", err)
 		c.ReturnErrorMsg(err)
 		return nil
 	}
-	logger.Info("genesis block:", block)
-	c.ReturnOKMsg("generate config block ok")
+	artifact, err := channel.SaveGenesisBlock(network, orgNames(orgs), block, c.principal().Authorize)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	if decode {
+		if artifact.Config, err = channel.GenesisConfig(block); err != nil {
+			c.ReturnErrorMsg(err)
+			return nil
+		}
+	}
+	c.ReturnOKMsg(artifact)
 	logger.Info("end genetate Genesis block")
 	return nil
 }
 
+// GenesisBlock downloads the genesis block of a network, or returns it
+// decoded with format=json
+func (c *ChannelController) GenesisBlock() error {
+	network := c.Ctx.Input.Param(":network")
+	artifact, data, err := channel.GenesisBlock(network)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	if err := c.principal().Authorize(artifact.Orgs...); err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	if c.GetString("format") == "json" {
+		c.genesisConfig(artifact, data)
+		return nil
+	}
+	c.Ctx.Output.Header("Content-Type", "application/octet-stream")
+	c.Ctx.Output.Header("Content-Disposition", "attachment; filename="+artifact.Network+".block")
+	c.Ctx.Output.Header("X-Checksum-Sha256", artifact.SHA256)
+	c.Ctx.Output.Body(data)
+	return nil
+}
+
+// genesisConfig replies artifact with the config of its block data
+func (c *ChannelController) genesisConfig(artifact *channel.GenesisArtifact, data []byte) {
+	var err error
+	if artifact.Config, err = channel.DecodeGenesisBlock(data); err != nil {
+		c.ReturnErrorMsg(err)
+		return
+	}
+	c.ReturnOKMsg(artifact)
+}
+
 // format: 'grpcs://xxxx:xx'
 func parseURL(rawURL string) *localconfig.AnchorPeer {
 	anchor := &localconfig.AnchorPeer{}
@@ -165,12 +335,12 @@ func (c *ChannelController) Identity() error {
 	// logger.Info("reqbody:", c.Ctx.Input.RequestBody)
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, idr)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 
 	orgs := idr.Orgs
-	newChannel, err := newChannel(orgs)
+	newChannel, err := newChannel(c.principal(), orgs)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
@@ -191,25 +361,23 @@ func (c *ChannelController) AddOrg() error {
 	addOrgReq := &channel.AddOrgRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, &addOrgReq)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 	orgs := addOrgReq.Orgs
 	channelName := addOrgReq.ChannelName
-	newChannel, err := newChannel(orgs)
+	newChannel, err := newChannel(c.principal(), orgs)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
 	}
+	newChannel.SetNetwork(&addOrgReq.NetworkConfig)
 	id := addOrgReq.Identity
-	err = newChannel.AddOrg(id, orgs, channelName)
-	if err != nil {
-		c.ReturnErrorMsg(err)
-		return nil
-	}
-
-	c.ReturnOKMsg("OK")
-	logger.Info("successfully add org.")
+	c.ServeConfigUpdate("add org", newChannel, func() (interface{}, error) {
+		return newChannel.PreviewAddOrg(id, channelName)
+	}, func() (interface{}, error) {
+		return "OK", newChannel.AddOrg(id, orgs, channelName)
+	})
 	return nil
 }
 
@@ -218,25 +386,399 @@ func (c *ChannelController) DeleteOrg() error {
 	delOrgReq := &channel.DeleteOrgRequest{}
 	err := json.Unmarshal(c.Ctx.Input.RequestBody, delOrgReq)
 	if err != nil {
-		c.ReturnErrorMsg(err)
+		c.ReturnBadRequest(err)
 		return nil
 	}
 	delOrg := delOrgReq.DelOrg
 	delOrderers := delOrgReq.DelOrderers
 	channelName := delOrgReq.ChannelName
 	operateOrg := delOrgReq.Orgs
-	newChannel, err := newChannel(operateOrg)
+	newChannel, err := newChannel(c.principal(), operateOrg)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	newChannel.SetNetwork(&delOrgReq.NetworkConfig)
+	c.ServeConfigUpdate("delete org", newChannel, func() (interface{}, error) {
+		return newChannel.PreviewDeleteOrg(delOrg, delOrderers, channelName)
+	}, func() (interface{}, error) {
+		return "OK", newChannel.DeleteOrg(delOrg, delOrderers, channelName, operateOrg)
+	})
+	return nil
+}
+
+// AddConsortiumOrg adds an org to a consortium of the system channel
+// without touching any application channel
+func (c *ChannelController) AddConsortiumOrg() error {
+	return c.consortiumOrg("add consortium org", func(ch *channel.Channel, req *channel.ConsortiumOrgRequest, dryRun bool) (*channel.ConfigUpdatePreview, error) {
+		return ch.AddConsortiumOrg(req.Identity, dryRun)
+	})
+}
+
+// DeleteConsortiumOrg removes an org from a consortium of the system
+// channel without touching any application channel
+func (c *ChannelController) DeleteConsortiumOrg() error {
+	return c.consortiumOrg("delete consortium org", func(ch *channel.Channel, req *channel.ConsortiumOrgRequest, dryRun bool) (*channel.ConfigUpdatePreview, error) {
+		return ch.DeleteConsortiumOrg(req.DelOrg, dryRun)
+	})
+}
+
+func (c *ChannelController) consortiumOrg(typ string, op func(*channel.Channel, *channel.ConsortiumOrgRequest, bool) (*channel.ConfigUpdatePreview, error)) error {
+	logger.Info("start " + typ)
+	req := &channel.ConsortiumOrgRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), req.Orgs)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	newChannel.SetNetwork(&req.NetworkConfig)
+	c.ServeConfigUpdate(typ, newChannel, func() (interface{}, error) {
+		return op(newChannel, req, true)
+	}, func() (interface{}, error) {
+		return op(newChannel, req, false)
+	})
+	return nil
+}
+
+// UpdateOrdererConfig changes the batch parameters and kafka brokers of
+// the channel
+func (c *ChannelController) UpdateOrdererConfig() error {
+	logger.Info("start update orderer config")
+	req := &channel.OrdererConfigRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), req.Orgs)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ServeConfigUpdate("update orderer config", newChannel, func() (interface{}, error) {
+		return newChannel.UpdateOrdererConfig(req.ChannelName, &req.BatchConfig, req.KafkaBrokers, true)
+	}, func() (interface{}, error) {
+		return newChannel.UpdateOrdererConfig(req.ChannelName, &req.BatchConfig, req.KafkaBrokers, false)
+	})
+	return nil
+}
+
+// AddConsenter adds an orderer node to the etcdraft consenters of the
+// system channel and of the channels
+func (c *ChannelController) AddConsenter() error {
+	logger.Info("start add consenter")
+	req := &channel.ConsenterRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), req.Orgs)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	newChannel.SetNetwork(&req.NetworkConfig)
+	c.ServeConfigUpdate("add consenter", newChannel, func() (interface{}, error) {
+		return partialPreviews(newChannel.AddConsenter(&req.Orderer, req.ChannelNames, true))
+	}, func() (interface{}, error) {
+		return partialPreviews(newChannel.AddConsenter(&req.Orderer, req.ChannelNames, false))
+	})
+	return nil
+}
+
+// RemoveConsenter removes an orderer node from the etcdraft consenters of
+// the system channel and of the channels
+func (c *ChannelController) RemoveConsenter() error {
+	logger.Info("start remove consenter")
+	req := &channel.ConsenterRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), req.Orgs)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	newChannel.SetNetwork(&req.NetworkConfig)
+	c.ServeConfigUpdate("remove consenter", newChannel, func() (interface{}, error) {
+		return partialPreviews(newChannel.RemoveConsenter(&req.Orderer, req.ChannelNames, true))
+	}, func() (interface{}, error) {
+		return partialPreviews(newChannel.RemoveConsenter(&req.Orderer, req.ChannelNames, false))
+	})
+	return nil
+}
+
+// UpdateAnchorPeers rewrites the anchor peers of the first org in the
+// channel
+func (c *ChannelController) UpdateAnchorPeers() error {
+	logger.Info("start update anchor peers")
+	req := &channel.AnchorPeersRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), req.Orgs[:1])
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
 	}
-	err = newChannel.DeleteOrg(delOrg, delOrderers, channelName, operateOrg)
+	c.ServeConfigUpdate("update anchor peers", newChannel, func() (interface{}, error) {
+		return newChannel.UpdateAnchorPeers(req.ChannelName, req.AnchorPeers, true)
+	}, func() (interface{}, error) {
+		return newChannel.UpdateAnchorPeers(req.ChannelName, req.AnchorPeers, false)
+	})
+	return nil
+}
+
+// UpdatePolicies replaces policies of the channel or of one of its orgs
+func (c *ChannelController) UpdatePolicies() error {
+	logger.Info("start update policies")
+	req := &channel.PoliciesRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), req.Orgs)
 	if err != nil {
 		c.ReturnErrorMsg(err)
 		return nil
 	}
+	c.ServeConfigUpdate("update policies", newChannel, func() (interface{}, error) {
+		return newChannel.UpdatePolicies(req.ChannelName, req.Org, req.Policies, true)
+	}, func() (interface{}, error) {
+		return newChannel.UpdatePolicies(req.ChannelName, req.Org, req.Policies, false)
+	})
+	return nil
+}
 
-	c.ReturnOKMsg("OK")
-	logger.Info("successfully delete org.")
+// UpgradeCapabilities raises the capabilities of channels
+func (c *ChannelController) UpgradeCapabilities() error {
+	logger.Info("start upgrade capabilities")
+	req := &channel.CapabilitiesRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), req.Orgs)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	newChannel.SetNetwork(&req.NetworkConfig)
+	c.ServeConfigUpdate("upgrade capabilities", newChannel, func() (interface{}, error) {
+		return partialPreviews(newChannel.UpgradeCapabilities(req.ChannelNames, &req.Capabilities, true))
+	}, func() (interface{}, error) {
+		return partialPreviews(newChannel.UpgradeCapabilities(req.ChannelNames, &req.Capabilities, false))
+	})
+	return nil
+}
+
+// partialPreviews keeps the previews of the channels updated before an
+// error as the result, there is none if the first channel failed
+func partialPreviews(previews []*channel.ConfigUpdatePreview, err error) (interface{}, error) {
+	if err != nil && len(previews) == 0 {
+		return nil, err
+	}
+	return previews, err
+}
+
+// Discovery ...
+func (c *ChannelController) Discovery() error {
+	logger.Info("start discovery channel")
+
+	channelName := c.Ctx.Input.Param(":name")
+	chaincode := c.GetString("chaincode")
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	resp, err := newChannel.Discovery(channelName, chaincode)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(resp)
+	logger.Info("successfully discovery channel")
 	return nil
 }
+
+// ChainInfo ...
+func (c *ChannelController) ChainInfo() error {
+	logger.Info("start get chain info")
+
+	channelName := c.Ctx.Input.Param(":name")
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	info, err := newChannel.ChainInfo(channelName)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(info)
+	logger.Info("successfully get chain info")
+	return nil
+}
+
+// Block ...
+func (c *ChannelController) Block() error {
+	logger.Info("start get block")
+
+	channelName := c.Ctx.Input.Param(":name")
+	number, err := strconv.ParseUint(c.Ctx.Input.Param(":number"), 10, 64)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	block, err := newChannel.Block(channelName, number)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(block)
+	logger.Info("successfully get block")
+	return nil
+}
+
+// Transaction ...
+func (c *ChannelController) Transaction() error {
+	logger.Info("start get transaction")
+
+	channelName := c.Ctx.Input.Param(":name")
+	txID := c.Ctx.Input.Param(":txid")
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	tx, err := newChannel.Transaction(channelName, txID)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(tx)
+	logger.Info("successfully get transaction")
+	return nil
+}
+
+// Events streams the blocks of the channel as Server-Sent Events, the id of
+// every event is the block number, so a client reconnecting with
+// Last-Event-ID goes on from the next block
+func (c *ChannelController) Events() error {
+	logger.Info("start subscribe channel events")
+
+	channelName := c.Ctx.Input.Param(":name")
+	start, err := c.GetInt64("start", -1)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if lastID := c.Ctx.Input.Header("Last-Event-ID"); lastID != "" {
+		last, err := strconv.ParseUint(lastID, 10, 64)
+		if err != nil {
+			c.ReturnBadRequest(err)
+			return nil
+		}
+		start = int64(last) + 1
+	}
+	full, err := c.GetBool("full", false)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	stream, err := newChannel.Subscribe(channelName, start, full)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	defer stream.Close()
+
+	c.EnableRender = false
+	w := c.Ctx.ResponseWriter
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.WriteHeader(200)
+	w.Flush()
+
+	closed := w.CloseNotify()
+	for {
+		select {
+		case <-closed:
+			logger.Info("subscriber of channel %s is gone", channelName)
+			return nil
+		case event, ok := <-stream.Events():
+			if !ok {
+				logger.Error("Error receiving channel events", stream.Err())
+				data, _ := json.Marshal(&protocols.ErrorMessage{Message: stream.Err().Error()})
+				fmt.Fprintf(w, "event: error\ndata: %s\n\n", data)
+				w.Flush()
+				return nil
+			}
+			data, err := json.Marshal(event)
+			if err != nil {
+				logger.Error("Error marshaling block event", err)
+				return nil
+			}
+			fmt.Fprintf(w, "id: %d\nevent: block\ndata: %s\n\n", event.Number, data)
+			w.Flush()
+		}
+	}
+}
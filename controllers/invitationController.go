@@ -0,0 +1,226 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"manageChain/channel"
+	"time"
+
+	logger "github.com/astaxie/beego/logs"
+)
+
+// StartInvitation invites an org into the channel through the public
+// chaincode, the members then vote by VoteInvitation
+func (c *ChannelController) StartInvitation() error {
+	logger.Info("start invitation")
+	req := &channel.StartInvitationRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	inviter, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	inviter.SetNetwork(&req.NetworkConfig)
+	c.Serve("start invitation", func(step func(string)) (interface{}, error) {
+		inviter.OnStep(step)
+		status, err := inviter.StartInvitation(req.ChannelName, req.Identity)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("successfully start invitation")
+		return completeInvitation(inviter, req.ChannelName, status)
+	})
+	return nil
+}
+
+// VoteInvitation votes Accept or Reject as the first org, the update adding
+// the invitee is proposed as soon as the admins policy of the channel is
+// satisfied
+func (c *ChannelController) VoteInvitation() error {
+	logger.Info("start vote invitation")
+	req := &channel.VoteInvitationRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	voter, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	voter.SetNetwork(&req.NetworkConfig)
+	c.Serve("vote invitation", func(step func(string)) (interface{}, error) {
+		voter.OnStep(step)
+		status, err := voter.VoteInvitation(req.ChannelName, req.Inviter, req.Invitee, req.Accept)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("successfully vote invitation")
+		return completeInvitation(voter, req.ChannelName, status)
+	})
+	return nil
+}
+
+// CompleteInvitation signs the update adding the invitee of an accepted
+// invitation as the first org, then submits it once ready
+func (c *ChannelController) CompleteInvitation() error {
+	logger.Info("start complete invitation")
+	req := &channel.CompleteInvitationRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	member, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	member.SetNetwork(&req.NetworkConfig)
+	c.Serve("complete invitation", func(step func(string)) (interface{}, error) {
+		member.OnStep(step)
+		status, err := member.InvitationStatus(req.ChannelName, req.Inviter, req.Invitee)
+		if err != nil {
+			return nil, err
+		}
+		return completeInvitation(member, req.ChannelName, status)
+	})
+	return nil
+}
+
+// Invitations ...
+func (c *ChannelController) Invitations() error {
+	logger.Info("start get invitations")
+
+	channelName := c.Ctx.Input.Param(":name")
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	invitations, err := newChannel.Invitations(channelName)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(invitations)
+	logger.Info("successfully get invitations")
+	return nil
+}
+
+// InvitationStatus ...
+func (c *ChannelController) InvitationStatus() error {
+	logger.Info("start get invitation status")
+
+	channelName := c.Ctx.Input.Param(":name")
+	inviter := c.Ctx.Input.Param(":inviter")
+	invitee := c.Ctx.Input.Param(":invitee")
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	status, err := newChannel.InvitationStatus(channelName, inviter, invitee)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(status)
+	logger.Info("successfully get invitation status")
+	return nil
+}
+
+// completeInvitation moves the invitation on once it's accepted, member
+// only signs as its own org
+func completeInvitation(member *channel.Channel, channelName string, status *channel.InvitationStatus) (*channel.InvitationStatus, error) {
+	if status.Decision != channel.InvitationAccepted {
+		return status, nil
+	}
+	if err := member.CompleteInvitation(channelName, status); err != nil {
+		return nil, err
+	}
+	if status.Decision == channel.InvitationConfirmed {
+		logger.Info("successfully add invitee %s", status.Invitation.Invitee)
+	}
+	return status, nil
+}
+
+// IssueInviteCode returns an invite code signed by the first org, the
+// invited org joins the channel by RedeemInviteCode
+func (c *ChannelController) IssueInviteCode() error {
+	logger.Info("start issue invite code")
+	req := &channel.InviteCodeRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	inviter, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	code, err := inviter.IssueInviteCode(req.ChannelName, time.Duration(req.ExpiresIn)*time.Second)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(code)
+	logger.Info("successfully issue invite code")
+	return nil
+}
+
+// RedeemInviteCode joins the peers of the first org with an invite code
+// issued by one of the trusted inviter CAs
+func (c *ChannelController) RedeemInviteCode() error {
+	logger.Info("start redeem invite code")
+	req := &channel.OrgJoinChannelRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	invitee, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.Serve("redeem invite code", func(step func(string)) (interface{}, error) {
+		invitee.OnStep(step)
+		resp, err := invitee.RedeemInviteCode(req.InviteCode, req.InviterCACerts)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("successfully redeem invite code")
+		return resp, nil
+	})
+	return nil
+}
@@ -0,0 +1,28 @@
+package controllers
+
+import (
+	"manageChain/jobs"
+	"manageChain/protocols"
+)
+
+type JobController struct {
+	BaseController
+}
+
+// GetJob returns a job submitted by the caller
+func (c *JobController) GetJob() error {
+	id := c.Ctx.Input.Param(":id")
+	job, err := jobs.Default().Get(c.principal().Name, id)
+	if err != nil {
+		c.ReturnErrorMsg(protocols.WrapError(protocols.CodeNotFound, err))
+		return nil
+	}
+	c.ReturnOKMsg(job)
+	return nil
+}
+
+// ListJobs returns the jobs submitted by the caller
+func (c *JobController) ListJobs() error {
+	c.ReturnOKMsg(jobs.Default().List(c.principal().Name))
+	return nil
+}
@@ -0,0 +1,231 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"manageChain/channel"
+
+	"github.com/astaxie/beego"
+	logger "github.com/astaxie/beego/logs"
+)
+
+// ProposeConfigUpdate stores a config update of the channel for its
+// members to sign, the first org computes it when needed
+func (c *ChannelController) ProposeConfigUpdate() error {
+	logger.Info("start propose config update")
+	req := &channel.PendingUpdateRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	proposer, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	proposer.SetNetwork(&req.NetworkConfig)
+
+	status, err := proposer.ProposeConfigUpdate(req)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(status)
+	logger.Info("successfully propose config update %s", status.ID)
+	return nil
+}
+
+// SignPendingUpdate adds the signature of the first org to a pending
+// update, or the one given by another instance or an offline signer, which
+// needs no local org
+func (c *ChannelController) SignPendingUpdate() error {
+	logger.Info("start sign pending update")
+	id := c.Ctx.Input.Param(":id")
+	req := &channel.PendingSignatureRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+
+	var status *channel.PendingUpdateStatus
+	if len(req.Signature) != 0 {
+		gm, _ := beego.AppConfig.Bool("GM")
+		status, err = channel.AddPendingSignature(id, req.SignatureHeader, req.Signature, gm)
+	} else {
+		if len(req.Orgs) == 0 {
+			c.ReturnBadRequest(errors.New("orgs should not be empty"))
+			return nil
+		}
+		var signer *channel.Channel
+		signer, err = newChannel(c.principal(), req.Orgs[:1])
+		if err != nil {
+			c.ReturnErrorMsg(err)
+			return nil
+		}
+		signer.SetNetwork(&req.NetworkConfig)
+		status, err = signer.SignPendingUpdate(id)
+	}
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(status)
+	logger.Info("successfully sign pending update %s", id)
+	return nil
+}
+
+// SignConfigUpdate signs a config update as the first org, the signature is
+// then posted to the pending update on the instance which keeps it
+func (c *ChannelController) SignConfigUpdate() error {
+	logger.Info("start sign config update")
+	req := &channel.ConfigSignRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	signer, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	signature, err := signer.SignConfigUpdate(req.ConfigUpdate)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(signature)
+	logger.Info("successfully sign config update")
+	return nil
+}
+
+// SubmitPendingUpdate broadcasts a pending update signed by enough members
+func (c *ChannelController) SubmitPendingUpdate() error {
+	logger.Info("start submit pending update")
+	id := c.Ctx.Input.Param(":id")
+	req := &channel.PendingSubmitRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	submitter, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	submitter.SetNetwork(&req.NetworkConfig)
+	c.Serve("submit pending update", func(step func(string)) (interface{}, error) {
+		submitter.OnStep(step)
+		status, err := submitter.SubmitPendingUpdate(id)
+		if err != nil {
+			return nil, err
+		}
+		logger.Info("successfully submit pending update %s", id)
+		return status, nil
+	})
+	return nil
+}
+
+// PendingUpdate ...
+func (c *ChannelController) PendingUpdate() error {
+	logger.Info("start get pending update")
+
+	id := c.Ctx.Input.Param(":id")
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	status, err := newChannel.PendingUpdateStatus(id)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(status)
+	logger.Info("successfully get pending update")
+	return nil
+}
+
+// PendingUpdates lists the pending updates of the channel to a caller
+// acting as the org of the request
+func (c *ChannelController) PendingUpdates() error {
+	logger.Info("start get pending updates")
+	org := c.GetString("org")
+	if org == "" {
+		c.ReturnBadRequest(errors.New("org should not be empty"))
+		return nil
+	}
+	if err := c.principal().Authorize(org); err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(channel.PendingUpdates(c.Ctx.Input.Param(":name")))
+	logger.Info("successfully get pending updates")
+	return nil
+}
+
+// ChannelConfig returns the latest config of the channel as json
+func (c *ChannelController) ChannelConfig() error {
+	logger.Info("start get channel config")
+
+	channelName := c.Ctx.Input.Param(":name")
+	newChannel, err := newChannel(c.principal(), []*channel.OrgInfo{c.orgFromQuery()})
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	config, err := newChannel.ConfigJSON(channelName)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(config)
+	logger.Info("successfully get channel config")
+	return nil
+}
+
+// EditChannelConfig proposes an edited config of the channel as a pending
+// update
+func (c *ChannelController) EditChannelConfig() error {
+	logger.Info("start edit channel config")
+	channelName := c.Ctx.Input.Param(":name")
+	req := &channel.ConfigEditRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if len(req.Orgs) == 0 {
+		c.ReturnBadRequest(errors.New("orgs should not be empty"))
+		return nil
+	}
+	editor, err := newChannel(c.principal(), req.Orgs[:1])
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ServeConfigUpdate("edit channel config", editor, func() (interface{}, error) {
+		return editor.PreviewConfigEdit(channelName, req.Config)
+	}, func() (interface{}, error) {
+		return editor.ProposeConfigEdit(channelName, req.Config)
+	})
+	return nil
+}
@@ -0,0 +1,101 @@
+package controllers
+
+import (
+	"encoding/json"
+	"manageChain/protocols"
+	"manageChain/signing"
+
+	logger "github.com/astaxie/beego/logs"
+)
+
+// SigningController lets callers keep their private keys, the server only
+// builds the bytes to sign and sends the signed ones
+type SigningController struct {
+	BaseController
+}
+
+// StartProposal ...
+func (c *SigningController) StartProposal() error {
+	logger.Info("start signing session of proposal")
+	req := &signing.ProposalRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if err := c.authorizeSigner(req.Signer); err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	resp, err := signing.StartProposal(c.principal().Name, req)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(resp)
+	return nil
+}
+
+// StartConfigUpdate ...
+func (c *SigningController) StartConfigUpdate() error {
+	logger.Info("start signing session of config update")
+	req := &signing.ConfigUpdateRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+	if err := c.authorizeSigner(req.Signer); err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+
+	resp, err := signing.StartConfigUpdate(c.principal().Name, req)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(resp)
+	return nil
+}
+
+// GetSession ...
+func (c *SigningController) GetSession() error {
+	resp, err := signing.Get(c.principal().Name, c.Ctx.Input.Param(":id"))
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(resp)
+	return nil
+}
+
+// Sign posts the signature of the ToSign of a session
+func (c *SigningController) Sign() error {
+	id := c.Ctx.Input.Param(":id")
+	logger.Info("start signing session %s", id)
+	req := &signing.SignatureRequest{}
+	err := json.Unmarshal(c.Ctx.Input.RequestBody, req)
+	if err != nil {
+		c.ReturnBadRequest(err)
+		return nil
+	}
+
+	resp, err := signing.Sign(c.principal().Name, id, req.Signature)
+	if err != nil {
+		c.ReturnErrorMsg(err)
+		return nil
+	}
+	c.ReturnOKMsg(resp)
+	return nil
+}
+
+// authorizeSigner checks the caller may act as the org of signer, msp ids
+// are the org names
+func (c *SigningController) authorizeSigner(signer *signing.Signer) error {
+	if signer == nil {
+		return protocols.Errorf(protocols.CodeBadRequest, "signer should not be empty")
+	}
+	return c.principal().Authorize(signer.MspID)
+}
@@ -0,0 +1,428 @@
+package jobs
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	logs "gglogs"
+	"io/ioutil"
+	"manageChain/protocols"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/astaxie/beego"
+)
+
+var logger *logs.BeeLogger
+
+func init() {
+	logger = logs.GetBeeLogger()
+}
+
+const (
+	defaultJobDir         = "jobdata"
+	defaultWebhookSchemes = "https"
+	defaultJobRetention   = 7 * 24 * time.Hour
+	webhookTimeout        = 10 * time.Second
+)
+
+// State of a job or of one of its steps
+type State string
+
+const (
+	Pending   State = "pending"
+	Running   State = "running"
+	Succeeded State = "succeeded"
+	Failed    State = "failed"
+)
+
+// ErrNotFound ...
+var ErrNotFound = errors.New("job not found")
+
+// Operation is the work of a job, step is called whenever a new step begins
+type Operation func(step func(name string)) (interface{}, error)
+
+type Step struct {
+	Name      string
+	State     State
+	StartTime time.Time
+	EndTime   time.Time
+	Error     string `json:",omitempty"`
+}
+
+type Job struct {
+	ID   string
+	Type string
+	// Owner is the name of the principal which submitted the job, only it
+	// may see the job
+	Owner      string
+	State      State
+	Steps      []*Step
+	Result     json.RawMessage         `json:",omitempty"`
+	Error      string                  `json:",omitempty"`
+	ErrorCode  string                  `json:",omitempty"`
+	Details    []protocols.ErrorDetail `json:",omitempty"`
+	Webhook    string                  `json:",omitempty"`
+	CreateTime time.Time
+	UpdateTime time.Time
+}
+
+// Options limit what the jobs of a manager may do
+type Options struct {
+	// WebhookSchemes and WebhookHosts are the urls a webhook may post to,
+	// hosts are matched by name or by name:port, no webhook is allowed
+	// when WebhookHosts is empty
+	WebhookSchemes []string
+	WebhookHosts   []string
+	// Retention is how long a finished job is kept, forever if zero
+	Retention time.Duration
+}
+
+// Manager runs jobs in background and keeps their state in dir,
+// one json file per job
+type Manager struct {
+	dir  string
+	opts Options
+	lock sync.Mutex
+	jobs map[string]*Job
+}
+
+var (
+	defaultManager *Manager
+	defaultOnce    sync.Once
+)
+
+// Default returns the manager persisting jobs to the JobDir of app.conf,
+// webhooks are limited to WebhookSchemes and WebhookHosts and finished
+// jobs are kept for JobRetention
+func Default() *Manager {
+	defaultOnce.Do(func() {
+		dir := beego.AppConfig.DefaultString("JobDir", defaultJobDir)
+		opts := Options{
+			WebhookSchemes: beego.AppConfig.DefaultStrings("WebhookSchemes", []string{defaultWebhookSchemes}),
+			WebhookHosts:   beego.AppConfig.Strings("WebhookHosts"),
+			Retention:      defaultJobRetention,
+		}
+		if retention := beego.AppConfig.String("JobRetention"); retention != "" {
+			d, err := time.ParseDuration(retention)
+			if err != nil {
+				logger.Error("Error parsing JobRetention %s: %s", retention, err)
+			} else {
+				opts.Retention = d
+			}
+		}
+		var err error
+		defaultManager, err = NewManager(dir, opts)
+		if err != nil {
+			logger.Error("Error loading jobs from %s: %s", dir, err)
+			defaultManager = &Manager{dir: dir, opts: opts, jobs: make(map[string]*Job)}
+		}
+	})
+	return defaultManager
+}
+
+// NewManager loads the jobs persisted in dir, the ones which were still
+// pending or running are failed since this process can't resume them
+func NewManager(dir string, opts Options) (*Manager, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	m := &Manager{
+		dir:  dir,
+		opts: opts,
+		jobs: make(map[string]*Job),
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		if file.IsDir() || !strings.HasSuffix(file.Name(), ".json") {
+			continue
+		}
+		data, err := ioutil.ReadFile(path.Join(dir, file.Name()))
+		if err != nil {
+			return nil, err
+		}
+		job := &Job{}
+		if err := json.Unmarshal(data, job); err != nil {
+			logger.Error("Error unmarshaling job %s: %s", file.Name(), err)
+			continue
+		}
+		if job.State == Pending || job.State == Running {
+			job.finish(nil, errors.New("interrupted by restart"))
+			if err := m.save(job); err != nil {
+				return nil, err
+			}
+		}
+		m.jobs[job.ID] = job
+	}
+	m.prune()
+	return m, nil
+}
+
+// Submit persists a new pending job of owner and runs op in background,
+// webhook gets the job posted once it's done
+func (m *Manager) Submit(typ string, owner string, webhook string, op Operation) (*Job, error) {
+	if webhook != "" {
+		if err := m.checkWebhook(webhook); err != nil {
+			return nil, err
+		}
+	}
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	job := &Job{
+		ID:         id,
+		Type:       typ,
+		Owner:      owner,
+		State:      Pending,
+		Webhook:    webhook,
+		CreateTime: now,
+		UpdateTime: now,
+	}
+
+	m.lock.Lock()
+	m.prune()
+	err = m.save(job)
+	if err == nil {
+		m.jobs[id] = job
+	}
+	snapshot := job.copy()
+	m.lock.Unlock()
+	if err != nil {
+		return nil, err
+	}
+
+	go m.run(job, op)
+	return snapshot, nil
+}
+
+// Get returns the job of owner, the jobs of others are not found
+func (m *Manager) Get(owner string, id string) (*Job, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	job, ok := m.jobs[id]
+	if !ok || job.Owner != owner {
+		return nil, ErrNotFound
+	}
+	return job.copy(), nil
+}
+
+// List returns the jobs of owner, the newest first
+func (m *Manager) List(owner string) []*Job {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.prune()
+	list := []*Job{}
+	for _, job := range m.jobs {
+		if job.Owner != owner {
+			continue
+		}
+		list = append(list, job.copy())
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].CreateTime.After(list[j].CreateTime)
+	})
+	return list
+}
+
+func (m *Manager) run(job *Job, op Operation) {
+	m.update(job, func() {
+		job.State = Running
+	})
+
+	result, err := op(func(name string) {
+		m.update(job, func() {
+			job.endStep(nil)
+			job.Steps = append(job.Steps, &Step{
+				Name:      name,
+				State:     Running,
+				StartTime: time.Now(),
+			})
+		})
+	})
+
+	var data []byte
+	if result != nil {
+		var marshalErr error
+		data, marshalErr = json.Marshal(result)
+		if err == nil {
+			err = marshalErr
+		}
+	}
+	m.update(job, func() {
+		job.finish(data, err)
+	})
+	if err != nil {
+		logger.Error("Job %s %s failed: %s", job.Type, job.ID, err)
+	} else {
+		logger.Info("Job %s %s succeeded", job.Type, job.ID)
+	}
+
+	if job.Webhook != "" {
+		m.notify(job)
+	}
+}
+
+func (m *Manager) update(job *Job, f func()) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	f()
+	job.UpdateTime = time.Now()
+	if err := m.save(job); err != nil {
+		logger.Error("Error saving job %s: %s", job.ID, err)
+	}
+}
+
+// save must be called with the lock held
+func (m *Manager) save(job *Job) error {
+	data, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+	tmp := path.Join(m.dir, job.ID+".json.tmp")
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path.Join(m.dir, job.ID+".json"))
+}
+
+// prune drops the jobs finished longer than the retention ago, it must be
+// called with the lock held
+func (m *Manager) prune() {
+	if m.opts.Retention <= 0 {
+		return
+	}
+	deadline := time.Now().Add(-m.opts.Retention)
+	for id, job := range m.jobs {
+		if job.State != Succeeded && job.State != Failed {
+			continue
+		}
+		if job.UpdateTime.After(deadline) {
+			continue
+		}
+		if err := os.Remove(path.Join(m.dir, id+".json")); err != nil && !os.IsNotExist(err) {
+			logger.Error("Error removing job %s: %s", id, err)
+			continue
+		}
+		delete(m.jobs, id)
+	}
+}
+
+// checkWebhook refuses the webhooks out of the configured schemes and
+// hosts, so a caller can't have this process post to any address it
+// reaches
+func (m *Manager) checkWebhook(webhook string) error {
+	u, err := url.Parse(webhook)
+	if err != nil {
+		return protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	if !containsFold(m.opts.WebhookSchemes, u.Scheme) {
+		return protocols.Errorf(protocols.CodeBadRequest, "webhook scheme %q is not allowed", u.Scheme)
+	}
+	if u.Host == "" || !containsFold(m.opts.WebhookHosts, u.Host) && !containsFold(m.opts.WebhookHosts, u.Hostname()) {
+		return protocols.Errorf(protocols.CodeBadRequest, "webhook host %q is not allowed", u.Host)
+	}
+	return nil
+}
+
+func containsFold(list []string, s string) bool {
+	for _, item := range list {
+		if strings.EqualFold(strings.TrimSpace(item), s) {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *Manager) notify(job *Job) {
+	snapshot, err := m.Get(job.Owner, job.ID)
+	if err != nil {
+		return
+	}
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		logger.Error("Error marshaling job", err)
+		return
+	}
+	// redirects would lead the post out of the allowed hosts
+	client := &http.Client{
+		Timeout: webhookTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	resp, err := client.Post(snapshot.Webhook, "application/json", bytes.NewReader(data))
+	if err != nil {
+		logger.Error("Error calling webhook of job %s: %s", job.ID, err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		logger.Error("Webhook of job %s replied %s", job.ID, resp.Status)
+	}
+}
+
+func (job *Job) endStep(err error) {
+	if len(job.Steps) == 0 {
+		return
+	}
+	last := job.Steps[len(job.Steps)-1]
+	if last.State != Running {
+		return
+	}
+	last.EndTime = time.Now()
+	if err != nil {
+		last.State = Failed
+		last.Error = err.Error()
+	} else {
+		last.State = Succeeded
+	}
+}
+
+func (job *Job) finish(result []byte, err error) {
+	job.endStep(err)
+	if err != nil {
+		job.State = Failed
+		if e, ok := err.(*protocols.Error); ok {
+			job.Error = e.Message
+			job.ErrorCode = string(e.Code)
+			job.Details = e.Details
+		} else {
+			job.Error = err.Error()
+		}
+		job.Result = result
+		return
+	}
+	job.State = Succeeded
+	job.Result = result
+}
+
+func (job *Job) copy() *Job {
+	cp := *job
+	cp.Steps = nil
+	for _, step := range job.Steps {
+		s := *step
+		cp.Steps = append(cp.Steps, &s)
+	}
+	return &cp
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
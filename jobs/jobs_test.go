@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+)
+
+func wait(t *testing.T, m *Manager, id string) *Job {
+	for i := 0; i < 100; i++ {
+		job, err := m.Get("ops", id)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if job.State == Succeeded || job.State == Failed {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatalf("job %s not done", id)
+	return nil
+}
+
+func TestJobs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := NewManager(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job, err := m.Submit("test", "ops", "", func(step func(string)) (interface{}, error) {
+		step("first")
+		step("second")
+		return "OK", nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = wait(t, m, job.ID)
+	if job.State != Succeeded || len(job.Steps) != 2 || string(job.Result) != `"OK"` {
+		t.Fatalf("unexpected job %+v", job)
+	}
+
+	failed, err := m.Submit("test", "ops", "", func(step func(string)) (interface{}, error) {
+		step("first")
+		return "half", errors.New("boom")
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failed = wait(t, m, failed.ID)
+	if failed.State != Failed || failed.Steps[0].State != Failed || failed.Error != "boom" || string(failed.Result) != `"half"` {
+		t.Fatalf("unexpected job %+v", failed)
+	}
+
+	reloaded, err := NewManager(dir, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reloaded.List("ops")) != 2 {
+		t.Fatalf("expected 2 persisted jobs, got %d", len(reloaded.List("ops")))
+	}
+	if _, err := reloaded.Get("ops", "unknown"); err != ErrNotFound {
+		t.Fatalf("expected ErrNotFound, got %v", err)
+	}
+	if _, err := reloaded.Get("dev", job.ID); err != ErrNotFound {
+		t.Fatalf("expected the job of another owner to be not found, got %v", err)
+	}
+	if len(reloaded.List("dev")) != 0 {
+		t.Fatal("expected no job listed for another owner")
+	}
+}
+
+func TestWebhookAndRetention(t *testing.T) {
+	dir, err := ioutil.TempDir("", "jobs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	m, err := NewManager(dir, Options{
+		WebhookSchemes: []string{"https"},
+		WebhookHosts:   []string{"hooks.example.com", "ops.example.com:8443"},
+		Retention:      time.Hour,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	noop := func(step func(string)) (interface{}, error) { return nil, nil }
+	for _, webhook := range []string{
+		"http://hooks.example.com/done",
+		"https://169.254.169.254/latest",
+		"https://ops.example.com/done",
+		"https:///done",
+	} {
+		if _, err := m.Submit("test", "ops", webhook, noop); err == nil {
+			t.Fatalf("expected webhook %s to be refused", webhook)
+		}
+	}
+	for _, webhook := range []string{
+		"https://hooks.example.com/done",
+		"https://HOOKS.example.com:9443/done",
+		"https://ops.example.com:8443/done",
+	} {
+		if err := m.checkWebhook(webhook); err != nil {
+			t.Fatalf("expected webhook %s to be allowed, got %s", webhook, err)
+		}
+	}
+
+	job, err := m.Submit("test", "ops", "", noop)
+	if err != nil {
+		t.Fatal(err)
+	}
+	wait(t, m, job.ID)
+	m.lock.Lock()
+	m.jobs[job.ID].UpdateTime = time.Now().Add(-2 * time.Hour)
+	m.lock.Unlock()
+	if len(m.List("ops")) != 0 {
+		t.Fatal("expected the expired job to be pruned")
+	}
+	if _, err := os.Stat(dir + "/" + job.ID + ".json"); !os.IsNotExist(err) {
+		t.Fatalf("expected the job file to be removed, got %v", err)
+	}
+}
@@ -0,0 +1,103 @@
+package protocols
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// ErrorCode is the machine readable kind of an error
+type ErrorCode string
+
+const (
+	CodeBadRequest        ErrorCode = "BAD_REQUEST"
+	CodeUnauthorized      ErrorCode = "UNAUTHORIZED"
+	CodeForbidden         ErrorCode = "FORBIDDEN"
+	CodeMSPNotFound       ErrorCode = "MSP_NOT_FOUND"
+	CodeNotFound          ErrorCode = "NOT_FOUND"
+	CodeConflict          ErrorCode = "CONFLICT"
+	CodeEndorsementFailed ErrorCode = "ENDORSEMENT_FAILED"
+	CodeBroadcastFailed   ErrorCode = "BROADCAST_FAILED"
+	CodeTxInvalid         ErrorCode = "TX_INVALID"
+	CodeTimeout           ErrorCode = "TIMEOUT"
+	CodeExpired           ErrorCode = "EXPIRED"
+	CodeInternal          ErrorCode = "INTERNAL_ERROR"
+)
+
+var httpStatus = map[ErrorCode]int{
+	CodeBadRequest:        http.StatusBadRequest,
+	CodeUnauthorized:      http.StatusUnauthorized,
+	CodeForbidden:         http.StatusForbidden,
+	CodeMSPNotFound:       http.StatusNotFound,
+	CodeNotFound:          http.StatusNotFound,
+	CodeConflict:          http.StatusConflict,
+	CodeEndorsementFailed: http.StatusBadGateway,
+	CodeBroadcastFailed:   http.StatusBadGateway,
+	CodeTxInvalid:         http.StatusConflict,
+	CodeTimeout:           http.StatusGatewayTimeout,
+	CodeExpired:           http.StatusGone,
+	CodeInternal:          http.StatusInternalServerError,
+}
+
+// HTTPStatus returns the status replied for code, 500 for unknown ones
+func HTTPStatus(code ErrorCode) int {
+	if status, ok := httpStatus[code]; ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// Error is an error carrying its code, so that the front end can
+// branch on it instead of the message
+type Error struct {
+	Code           ErrorCode
+	Message        string
+	ValidationCode string
+	Details        []ErrorDetail
+}
+
+// Errorf ...
+func Errorf(code ErrorCode, format string, args ...interface{}) *Error {
+	return &Error{
+		Code:    code,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// WrapError gives code to err, errors already coded are kept as they are
+func WrapError(code ErrorCode, err error) *Error {
+	if e, ok := err.(*Error); ok {
+		return e
+	}
+	return &Error{
+		Code:    code,
+		Message: err.Error(),
+	}
+}
+
+// AddDetail records why endpoint failed
+func (e *Error) AddDetail(endpoint string, err error) *Error {
+	e.Details = append(e.Details, ErrorDetail{
+		Endpoint: endpoint,
+		Message:  err.Error(),
+	})
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.ValidationCode != "" {
+		return fmt.Sprintf("%s: %s, validation code: %s", e.Code, e.Message, e.ValidationCode)
+	}
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// ToMessage converts err to the message given to the front end,
+// errors without code are internal ones
+func ToMessage(err error) (int, *ErrorMessage) {
+	e := WrapError(CodeInternal, err)
+	return HTTPStatus(e.Code), &ErrorMessage{
+		Code:           string(e.Code),
+		Message:        e.Message,
+		ValidationCode: e.ValidationCode,
+		Details:        e.Details,
+	}
+}
@@ -0,0 +1,21 @@
+package protocols
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestToMessage(t *testing.T) {
+	err := Errorf(CodeBroadcastFailed, "failed broadcasting through all orderers").
+		AddDetail("orderer0:7050", errors.New("connection refused"))
+	status, msg := ToMessage(err)
+	if status != http.StatusBadGateway || msg.Code != string(CodeBroadcastFailed) || len(msg.Details) != 1 {
+		t.Fatalf("unexpected message %d %+v", status, msg)
+	}
+
+	status, msg = ToMessage(errors.New("boom"))
+	if status != http.StatusInternalServerError || msg.Code != string(CodeInternal) || msg.Message != "boom" {
+		t.Fatalf("unexpected message %d %+v", status, msg)
+	}
+}
@@ -2,6 +2,17 @@ package protocols
 
 // ErrorMessage uses for describe the error message and give it to the front end
 type ErrorMessage struct {
-	Code    string `json:"code"`
-	Message string `json:"message"`
+	Code           string        `json:"code"`
+	Message        string        `json:"message"`
+	ValidationCode string        `json:"validationCode,omitempty"`
+	Details        []ErrorDetail `json:"details,omitempty"`
+	// Result is what was done before the error, for the operations which
+	// stop halfway like upgrading the capabilities of several channels
+	Result interface{} `json:"result,omitempty"`
+}
+
+// ErrorDetail tells why one peer or orderer failed
+type ErrorDetail struct {
+	Endpoint string `json:"endpoint"`
+	Message  string `json:"message"`
 }
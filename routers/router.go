@@ -1,6 +1,7 @@
 package routers
 
 import (
+	"manageChain/auth"
 	"manageChain/controllers"
 
 	"github.com/astaxie/beego"
@@ -16,18 +17,67 @@ func init() {
 	// 	AllowOrigins:     beego.AppConfig.Strings("Allowip"),
 	// }))
 
+	// refuse to start rather than serve anyone as any org
+	authenticators, err := auth.FromConfig()
+	if err != nil {
+		panic("Error loading authentication: " + err.Error())
+	}
+	beego.InsertFilter("/*", beego.BeforeRouter, auth.NewFilter(authenticators))
+
 	beego.Router("/", &controllers.MainController{})
 	beego.Router("/gencrypto", &controllers.ChannelController{}, "post:GenCrypto")
+	beego.Router("/org/users", &controllers.ChannelController{}, "post:AddUsers")
+	beego.Router("/org/:org/users", &controllers.ChannelController{}, "get:Users")
 	beego.Router("/gengenesisblock", &controllers.ChannelController{}, "post:GenGenesisBlock")
+	beego.Router("/genesisblocks/:network", &controllers.ChannelController{}, "get:GenesisBlock")
 	// beego.Router("/genchannelconfig", &controllers.ChannelController{}, "post:GenChannelConfig")
 	beego.Router("/channel/identity", &controllers.ChannelController{}, "post:Identity")
 	beego.Router("/channel/addorg", &controllers.ChannelController{}, "post:AddOrg")
 	beego.Router("/channel/deleteorg", &controllers.ChannelController{}, "post:DeleteOrg")
+	beego.Router("/consortium/addorg", &controllers.ChannelController{}, "post:AddConsortiumOrg")
+	beego.Router("/consortium/deleteorg", &controllers.ChannelController{}, "post:DeleteConsortiumOrg")
 	beego.Router("/channel/create", &controllers.ChannelController{}, "post:CreateChannel")
 	beego.Router("/channel/join", &controllers.ChannelController{}, "post:JoinChannel")
+	beego.Router("/channel/invitation/start", &controllers.ChannelController{}, "post:StartInvitation")
+	beego.Router("/channel/invitation/vote", &controllers.ChannelController{}, "post:VoteInvitation")
+	beego.Router("/channel/invitation/complete", &controllers.ChannelController{}, "post:CompleteInvitation")
+	beego.Router("/channel/invitecode", &controllers.ChannelController{}, "post:IssueInviteCode")
+	beego.Router("/channel/invitecode/redeem", &controllers.ChannelController{}, "post:RedeemInviteCode")
+	beego.Router("/channel/:name/invitations", &controllers.ChannelController{}, "get:Invitations")
+	beego.Router("/channel/:name/invitations/:inviter/:invitee", &controllers.ChannelController{}, "get:InvitationStatus")
+	beego.Router("/channel/capabilities", &controllers.ChannelController{}, "post:UpgradeCapabilities")
+	beego.Router("/channel/policies", &controllers.ChannelController{}, "post:UpdatePolicies")
+	beego.Router("/channel/anchorpeers", &controllers.ChannelController{}, "post:UpdateAnchorPeers")
+	beego.Router("/channel/orderer", &controllers.ChannelController{}, "post:UpdateOrdererConfig")
+	beego.Router("/channel/consenters/add", &controllers.ChannelController{}, "post:AddConsenter")
+	beego.Router("/channel/consenters/remove", &controllers.ChannelController{}, "post:RemoveConsenter")
+	beego.Router("/channel/configupdates", &controllers.ChannelController{}, "post:ProposeConfigUpdate")
+	beego.Router("/channel/configupdates/:id", &controllers.ChannelController{}, "get:PendingUpdate")
+	beego.Router("/channel/configupdates/:id/signatures", &controllers.ChannelController{}, "post:SignPendingUpdate")
+	beego.Router("/channel/configupdates/:id/submit", &controllers.ChannelController{}, "post:SubmitPendingUpdate")
+	beego.Router("/channel/configsignatures", &controllers.ChannelController{}, "post:SignConfigUpdate")
+	beego.Router("/channel/:name/config", &controllers.ChannelController{}, "get:ChannelConfig;post:EditChannelConfig")
+	beego.Router("/channel/:name/configupdates", &controllers.ChannelController{}, "get:PendingUpdates")
+	beego.Router("/channel/:name/discovery", &controllers.ChannelController{}, "get:Discovery")
+	beego.Router("/channel/:name/info", &controllers.ChannelController{}, "get:ChainInfo")
+	beego.Router("/channel/:name/blocks/:number", &controllers.ChannelController{}, "get:Block")
+	beego.Router("/channel/:name/tx/:txid", &controllers.ChannelController{}, "get:Transaction")
+	beego.Router("/channel/:name/events", &controllers.ChannelController{}, "get:Events")
+
+	beego.Router("/sign/proposal", &controllers.SigningController{}, "post:StartProposal")
+	beego.Router("/sign/configupdate", &controllers.SigningController{}, "post:StartConfigUpdate")
+	beego.Router("/sign/sessions/:id", &controllers.SigningController{}, "get:GetSession")
+	beego.Router("/sign/sessions/:id/signature", &controllers.SigningController{}, "post:Sign")
+
+	beego.Router("/jobs", &controllers.JobController{}, "get:ListJobs")
+	beego.Router("/jobs/:id", &controllers.JobController{}, "get:GetJob")
 
 	beego.Router("/chaincode/install", &controllers.ChaincodeController{}, "post:InstallChaincode")
 	beego.Router("/chaincode/instantiate", &controllers.ChaincodeController{}, "post:InstantiateChaincode")
-	beego.Router("/chaincode/invoke ", &controllers.ChaincodeController{}, "post:Invoke")
+	beego.Router("/chaincode/upgrade", &controllers.ChaincodeController{}, "post:UpgradeChaincode")
+	beego.Router("/chaincode/invoke", &controllers.ChaincodeController{}, "post:Invoke")
+	beego.Router("/chaincode/installed", &controllers.ChaincodeController{}, "post:InstalledChaincodes")
+	beego.Router("/chaincode/instantiated", &controllers.ChaincodeController{}, "post:InstantiatedChaincodes")
+	beego.Router("/chaincode/query", &controllers.ChaincodeController{}, "post:Query")
 
 }
@@ -0,0 +1,102 @@
+package signing
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"manageChain/protocols"
+	"sync"
+	"time"
+
+	cb "github.com/hyperledger/fabric/protos/common"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+// session keeps what is needed between two signatures, owner is the
+// principal which started it
+type session struct {
+	id          string
+	owner       string
+	kind        string
+	stage       string
+	channelName string
+	txID        string
+	creator     []byte
+	toSign      []byte
+	expiresAt   time.Time
+
+	peers    []*sdk.Endpoint
+	orderers []*sdk.Endpoint
+
+	proposal     []byte
+	endorsements []*EndorsementInfo
+	responses    [][]byte
+
+	configUpdate []byte
+	sigs         []*cb.ConfigSignature
+
+	// lock serializes the signatures posted to the session
+	lock sync.Mutex
+}
+
+func (s *session) response() *SessionResponse {
+	resp := &SessionResponse{
+		SessionID:    s.id,
+		Kind:         s.kind,
+		Stage:        s.stage,
+		ChannelName:  s.channelName,
+		TxID:         s.txID,
+		Endorsements: s.endorsements,
+		ExpiresAt:    s.expiresAt,
+	}
+	if s.stage != StageDone {
+		resp.ToSign = s.toSign
+	}
+	return resp
+}
+
+// store holds the sessions in memory, they are dropped once expired
+type store struct {
+	lock     sync.Mutex
+	sessions map[string]*session
+}
+
+var sessions = &store{sessions: make(map[string]*session)}
+
+func (st *store) add(s *session) error {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return err
+	}
+	s.id = hex.EncodeToString(b)
+	s.expiresAt = time.Now().Add(SessionTTL)
+
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.purge()
+	st.sessions[s.id] = s
+	return nil
+}
+
+func (st *store) get(id string, owner string) (*session, error) {
+	st.lock.Lock()
+	defer st.lock.Unlock()
+	st.purge()
+	s, ok := st.sessions[id]
+	if !ok {
+		return nil, protocols.Errorf(protocols.CodeNotFound, "signing session %s not found or expired", id)
+	}
+	if s.owner != owner {
+		return nil, protocols.Errorf(protocols.CodeForbidden, "signing session %s belongs to another caller", id)
+	}
+	return s, nil
+}
+
+// purge must be called with the lock held
+func (st *store) purge() {
+	now := time.Now()
+	for id, s := range st.sessions {
+		if now.After(s.expiresAt) {
+			delete(st.sessions, id)
+		}
+	}
+}
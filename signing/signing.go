@@ -0,0 +1,244 @@
+package signing
+
+import (
+	logs "gglogs"
+	"manageChain/protocols"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric/protos/common"
+	mspprotos "github.com/hyperledger/fabric/protos/msp"
+	"github.com/hyperledger/fabric/sdk"
+)
+
+var logger *logs.BeeLogger
+
+func init() {
+	logger = logs.GetBeeLogger()
+}
+
+// StartProposal creates the proposal of req for the signer of req, the
+// caller then signs ToSign and posts it by Sign
+func StartProposal(owner string, req *ProposalRequest) (*SessionResponse, error) {
+	if req.ChannelName == "" || req.CcName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name and chaincode name should not be empty")
+	}
+	if len(req.PeerNodes) == 0 || (!req.Query && len(req.OrdererNodes) == 0) {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "no peers or orderers can be found")
+	}
+	creator, err := serializeSigner(req.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	txID, proposal, err := sdk.CreateChaincodeProposalBytes(req.ChannelName, req.CcName, req.Args, nil, creator)
+	if err != nil {
+		logger.Error("Error creating proposal", err)
+		return nil, err
+	}
+
+	s := &session{
+		owner:       owner,
+		kind:        KindTransaction,
+		stage:       StageProposal,
+		channelName: req.ChannelName,
+		txID:        txID,
+		creator:     creator,
+		toSign:      proposal,
+		proposal:    proposal,
+		peers:       endpoints(req.PeerNodes),
+		orderers:    endpoints(req.OrdererNodes),
+	}
+	if req.Query {
+		s.kind = KindQuery
+	}
+	if err := sessions.add(s); err != nil {
+		return nil, err
+	}
+	logger.Info("started %s signing session %s", s.kind, s.id)
+	return s.response(), nil
+}
+
+// StartConfigUpdate creates the config signature of req for the signer of
+// req, the caller then signs ToSign and posts it by Sign
+func StartConfigUpdate(owner string, req *ConfigUpdateRequest) (*SessionResponse, error) {
+	if req.ChannelName == "" {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "channel name should not be empty")
+	}
+	if len(req.OrdererNodes) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "no orderers can be found")
+	}
+	creator, err := serializeSigner(req.Signer)
+	if err != nil {
+		return nil, err
+	}
+	update := &cb.ConfigUpdate{}
+	if err := proto.Unmarshal(req.ConfigUpdate, update); err != nil {
+		return nil, protocols.WrapError(protocols.CodeBadRequest, err)
+	}
+	if update.ChannelId != req.ChannelName {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "config update is for channel %s, not %s", update.ChannelId, req.ChannelName)
+	}
+
+	configUpdate, sigHeader, toSign, err := sdk.CreateConfigUpdateEnvelopeBytes(creator, update)
+	if err != nil {
+		logger.Error("Error creating config update envelope", err)
+		return nil, err
+	}
+
+	s := &session{
+		owner:        owner,
+		kind:         KindConfigUpdate,
+		stage:        StageConfigUpdate,
+		channelName:  req.ChannelName,
+		creator:      creator,
+		toSign:       toSign,
+		configUpdate: configUpdate,
+		sigs:         []*cb.ConfigSignature{{SignatureHeader: sigHeader}},
+		orderers:     endpoints(req.OrdererNodes),
+	}
+	if err := sessions.add(s); err != nil {
+		return nil, err
+	}
+	logger.Info("started %s signing session %s", s.kind, s.id)
+	return s.response(), nil
+}
+
+// Get ...
+func Get(owner string, id string) (*SessionResponse, error) {
+	s, err := sessions.get(id, owner)
+	if err != nil {
+		return nil, err
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.response(), nil
+}
+
+// Sign takes the signature of the ToSign of session id and goes on to the
+// next stage: proposals are endorsed, transactions and config envelopes
+// are broadcast
+func Sign(owner string, id string, signature []byte) (*SessionResponse, error) {
+	s, err := sessions.get(id, owner)
+	if err != nil {
+		return nil, err
+	}
+	if len(signature) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "signature should not be empty")
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	switch s.stage {
+	case StageProposal:
+		err = s.endorse(signature)
+	case StageTransaction, StageConfigEnvelope:
+		err = s.broadcast(signature)
+	case StageConfigUpdate:
+		err = s.signConfigUpdate(signature)
+	default:
+		err = protocols.Errorf(protocols.CodeBadRequest, "signing session %s is %s", id, s.stage)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.expiresAt = time.Now().Add(SessionTTL)
+	logger.Info("signing session %s goes to %s", s.id, s.stage)
+	return s.response(), nil
+}
+
+func (s *session) endorse(signature []byte) error {
+	failed := protocols.Errorf(protocols.CodeEndorsementFailed, "failed proposing through all peers")
+	s.endorsements = nil
+	s.responses = nil
+	for _, peer := range s.peers {
+		resps, err := sdk.Endorse(s.proposal, signature, []*sdk.Endpoint{peer})
+		if err != nil {
+			logger.Error("Error endorsing", err)
+			failed.AddDetail(peer.Address, err)
+			continue
+		}
+		for _, resp := range resps {
+			info := &EndorsementInfo{Endorser: peer.Address}
+			if resp.Response != nil {
+				info.Status = resp.Response.Status
+				info.Message = resp.Response.Message
+				info.Payload = resp.Response.Payload
+			}
+			s.endorsements = append(s.endorsements, info)
+			if info.Status >= 400 {
+				failed.AddDetail(peer.Address, protocols.Errorf(protocols.CodeEndorsementFailed, "status %d: %s", info.Status, info.Message))
+				continue
+			}
+			data, err := proto.Marshal(resp)
+			if err != nil {
+				return err
+			}
+			s.responses = append(s.responses, data)
+		}
+	}
+	if len(failed.Details) > 0 {
+		return failed
+	}
+
+	if s.kind == KindQuery {
+		s.stage = StageDone
+		return nil
+	}
+	payload, err := sdk.CreateChaincodeEnvelopeBytesFromBytes(s.proposal, s.responses)
+	if err != nil {
+		logger.Error("Error creating transaction", err)
+		return protocols.WrapError(protocols.CodeEndorsementFailed, err)
+	}
+	s.toSign = payload
+	s.stage = StageTransaction
+	return nil
+}
+
+func (s *session) signConfigUpdate(signature []byte) error {
+	s.sigs[0].Signature = signature
+	payload, err := sdk.CreateChannelEnvelopeBytes(s.channelName, s.creator, s.configUpdate, s.sigs)
+	if err != nil {
+		logger.Error("Error creating config envelope", err)
+		return err
+	}
+	s.toSign = payload
+	s.stage = StageConfigEnvelope
+	return nil
+}
+
+func (s *session) broadcast(signature []byte) error {
+	failed := protocols.Errorf(protocols.CodeBroadcastFailed, "failed broadcasting through all orderers")
+	for _, orderer := range s.orderers {
+		if err := sdk.Broadcast(s.toSign, signature, orderer); err != nil {
+			logger.Error("Error broadcasting", err)
+			failed.AddDetail(orderer.Address, err)
+			continue
+		}
+		s.stage = StageDone
+		return nil
+	}
+	return failed
+}
+
+func serializeSigner(signer *Signer) ([]byte, error) {
+	if signer == nil || signer.MspID == "" || len(signer.Certificate) == 0 {
+		return nil, protocols.Errorf(protocols.CodeBadRequest, "signer msp id and certificate should not be empty")
+	}
+	return proto.Marshal(&mspprotos.SerializedIdentity{
+		Mspid:   signer.MspID,
+		IdBytes: signer.Certificate,
+	})
+}
+
+func endpoints(nodes []*ServiceNode) []*sdk.Endpoint {
+	var list []*sdk.Endpoint
+	for _, node := range nodes {
+		list = append(list, &sdk.Endpoint{
+			Address: node.Endpoint,
+			TLS:     node.TLSCACert,
+			Timeout: EndpointTimeout,
+		})
+	}
+	return list
+}
@@ -0,0 +1,83 @@
+package signing
+
+import (
+	"time"
+)
+
+const (
+	// SessionTTL is how long a session waits for the next signature
+	SessionTTL      = 10 * time.Minute
+	EndpointTimeout = 5 * time.Second
+)
+
+// kinds of session
+const (
+	KindTransaction  = "transaction"
+	KindQuery        = "query"
+	KindConfigUpdate = "config update"
+)
+
+// stages of a session, ToSign of the session is what the caller signs next
+const (
+	StageProposal       = "sign proposal"
+	StageTransaction    = "sign transaction"
+	StageConfigUpdate   = "sign config update"
+	StageConfigEnvelope = "sign config envelope"
+	StageDone           = "done"
+)
+
+type ServiceNode struct {
+	Endpoint  string
+	TLSCACert []byte
+}
+
+// Signer is the identity signing on the caller side, Certificate is
+// its PEM encoded certificate
+type Signer struct {
+	MspID       string
+	Certificate []byte
+}
+
+// ProposalRequest starts a session for a chaincode proposal, the
+// transaction is ordered unless Query is set
+type ProposalRequest struct {
+	Signer       *Signer
+	ChannelName  string
+	CcName       string
+	Args         [][]byte
+	Query        bool
+	PeerNodes    []*ServiceNode
+	OrdererNodes []*ServiceNode
+}
+
+// ConfigUpdateRequest starts a session for a marshaled common.ConfigUpdate
+type ConfigUpdateRequest struct {
+	Signer       *Signer
+	ChannelName  string
+	ConfigUpdate []byte
+	OrdererNodes []*ServiceNode
+}
+
+// SignatureRequest carries the signature of the ToSign of a session
+type SignatureRequest struct {
+	Signature []byte
+}
+
+type EndorsementInfo struct {
+	Endorser string
+	Status   int32
+	Message  string
+	Payload  []byte
+}
+
+// SessionResponse is the state of a session given to the caller
+type SessionResponse struct {
+	SessionID    string
+	Kind         string
+	Stage        string
+	ChannelName  string
+	TxID         string `json:",omitempty"`
+	ToSign       []byte `json:",omitempty"`
+	Endorsements []*EndorsementInfo
+	ExpiresAt    time.Time
+}
@@ -0,0 +1,49 @@
+package signing
+
+import (
+	"testing"
+
+	"github.com/golang/protobuf/proto"
+	cb "github.com/hyperledger/fabric/protos/common"
+)
+
+func TestConfigUpdateSession(t *testing.T) {
+	update, err := proto.Marshal(&cb.ConfigUpdate{ChannelId: "channel1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := &ConfigUpdateRequest{
+		Signer: &Signer{
+			MspID:       "testorg1",
+			Certificate: []byte("-----BEGIN CERTIFICATE-----"),
+		},
+		ChannelName:  "channel1",
+		ConfigUpdate: update,
+		OrdererNodes: []*ServiceNode{&ServiceNode{Endpoint: "127.0.0.1:7050"}},
+	}
+
+	resp, err := StartConfigUpdate("ops", req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Stage != StageConfigUpdate || len(resp.ToSign) == 0 {
+		t.Fatalf("unexpected session %+v", resp)
+	}
+
+	if _, err := Sign("robot", resp.SessionID, []byte("signature")); err == nil {
+		t.Fatal("expected error signing the session of another caller")
+	}
+
+	resp, err = Sign("ops", resp.SessionID, []byte("signature"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Stage != StageConfigEnvelope || len(resp.ToSign) == 0 {
+		t.Fatalf("unexpected session %+v", resp)
+	}
+
+	req.ChannelName = "channel2"
+	if _, err := StartConfigUpdate("ops", req); err == nil {
+		t.Fatal("expected error for config update of another channel")
+	}
+}